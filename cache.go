@@ -0,0 +1,294 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores the results of compilations for typst.CachingCaller.
+//
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value that is stored for the given key, and whether it was found.
+	Get(key string) ([]byte, bool)
+
+	// Put stores the value for the given key.
+	// The cache may drop the value at any time, e.g. because it's too large.
+	Put(key string, value []byte)
+}
+
+// MemoryCache is a Cache that keeps values in memory.
+// Once the total size of all values exceeds the limit, the least recently used values are evicted.
+//
+// Use NewMemoryCache to create one.
+type MemoryCache struct {
+	mutex    sync.Mutex
+	maxBytes int64
+	size     int64
+	entries  map[string]*list.Element
+	lru      *list.List // Contains *memoryCacheEntry, most recently used first.
+}
+
+// memoryCacheEntry is a single value of a MemoryCache.
+type memoryCacheEntry struct {
+	key   string
+	value []byte
+}
+
+// Ensure that MemoryCache implements the Cache interface.
+var _ Cache = &MemoryCache{}
+
+// NewMemoryCache returns a new MemoryCache that holds values up to the given total size in bytes.
+func NewMemoryCache(maxBytes int64) *MemoryCache {
+	return &MemoryCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Get implements the Cache interface.
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+
+	return element.Value.(*memoryCacheEntry).value, true
+}
+
+// Put implements the Cache interface.
+// Values that are larger than the limit are not stored.
+func (c *MemoryCache) Put(key string, value []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	if int64(len(value)) > c.maxBytes {
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&memoryCacheEntry{key: key, value: value})
+	c.size += int64(len(value))
+
+	for c.size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove removes the given element from the cache.
+func (c *MemoryCache) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(*memoryCacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= int64(len(entry.value))
+}
+
+// Len returns the number of values in the cache.
+func (c *MemoryCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.lru.Len()
+}
+
+// Size returns the total size of all values in the cache in bytes.
+func (c *MemoryCache) Size() int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.size
+}
+
+// diskCacheKeyRegex matches keys that can be used as file names.
+var diskCacheKeyRegex = regexp.MustCompile(`^[0-9a-zA-Z_-]+$`)
+
+// DiskCache is a Cache that stores values as files in a directory.
+// Once the total size of all files exceeds the limit, the least recently used files are removed.
+//
+// The directory should be used exclusively by the cache.
+// Files that exist in the directory on creation are reused, so the cache persists between restarts.
+//
+// Use NewDiskCache to create one.
+type DiskCache struct {
+	mutex    sync.Mutex
+	dir      string
+	maxBytes int64
+	size     int64
+	entries  map[string]*list.Element
+	lru      *list.List // Contains *diskCacheEntry, most recently used first.
+}
+
+// diskCacheEntry is a single file of a DiskCache.
+type diskCacheEntry struct {
+	key  string
+	size int64
+}
+
+// Ensure that DiskCache implements the Cache interface.
+var _ Cache = &DiskCache{}
+
+// NewDiskCache returns a new DiskCache that stores up to the given total size in bytes inside of dir.
+// The directory is created if it doesn't exist.
+func NewDiskCache(dir string, maxBytes int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	type existingFile struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+	var files []existingFile
+	for _, dirEntry := range dirEntries {
+		if strings.HasPrefix(dirEntry.Name(), ".tmp-") {
+			// Leftover of an interrupted write.
+			os.Remove(filepath.Join(dir, dirEntry.Name())) //nolint:errcheck
+			continue
+		}
+		if !dirEntry.Type().IsRegular() || !diskCacheKeyRegex.MatchString(dirEntry.Name()) {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, existingFile{key: dirEntry.Name(), size: info.Size(), modTime: info.ModTime()})
+	}
+
+	// The modification time is updated on every access, so it represents the usage order.
+	slices.SortFunc(files, func(a, b existingFile) int { return b.modTime.Compare(a.modTime) })
+
+	c := &DiskCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+	for _, file := range files {
+		c.entries[file.key] = c.lru.PushBack(&diskCacheEntry{key: file.key, size: file.size})
+		c.size += file.size
+	}
+	c.evict()
+
+	return c, nil
+}
+
+// Get implements the Cache interface.
+func (c *DiskCache) Get(key string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	path := filepath.Join(c.dir, key)
+	value, err := os.ReadFile(path)
+	if err != nil {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(element)
+	now := time.Now()
+	os.Chtimes(path, now, now) //nolint:errcheck
+
+	return value, true
+}
+
+// Put implements the Cache interface.
+// Values that are larger than the limit, or that can't be written, are not stored.
+func (c *DiskCache) Put(key string, value []byte) {
+	if !diskCacheKeyRegex.MatchString(key) {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	if int64(len(value)) > c.maxBytes {
+		return
+	}
+
+	// Write into a temporary file first, so that there are never partially written values.
+	f, err := os.CreateTemp(c.dir, ".tmp-")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(value)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(c.dir, key))
+	}
+	if err != nil {
+		os.Remove(f.Name()) //nolint:errcheck
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&diskCacheEntry{key: key, size: int64(len(value))})
+	c.size += int64(len(value))
+	c.evict()
+}
+
+// evict removes the least recently used files until the total size is within the limit.
+func (c *DiskCache) evict() {
+	for c.size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove removes the given element and its file from the cache.
+func (c *DiskCache) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(*diskCacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+
+	// If this fails, the file will be reused or removed on the next start.
+	os.Remove(filepath.Join(c.dir, entry.key)) //nolint:errcheck
+}
+
+// Len returns the number of values in the cache.
+func (c *DiskCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.lru.Len()
+}
+
+// Size returns the total size of all values in the cache in bytes.
+func (c *DiskCache) Size() int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.size
+}
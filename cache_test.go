@@ -0,0 +1,71 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst_test
+
+import (
+	"testing"
+
+	"github.com/Dadido3/go-typst"
+)
+
+func testCacheEviction(t *testing.T, cache typst.Cache) {
+	cache.Put("a", []byte("aaaa"))
+	cache.Put("b", []byte("bbbb"))
+
+	// Access a, so that b is the least recently used value.
+	if value, ok := cache.Get("a"); !ok || string(value) != "aaaa" {
+		t.Fatalf("Unexpected value for key %q. Got %q, %t.", "a", value, ok)
+	}
+
+	// This exceeds the limit of 10 bytes, so b has to be evicted.
+	cache.Put("c", []byte("cccc"))
+
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("Expected key %q to be evicted.", "b")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("Expected key %q to be cached.", key)
+		}
+	}
+
+	// Values that are larger than the limit are not stored at all.
+	cache.Put("d", []byte("ddddddddddd"))
+	if _, ok := cache.Get("d"); ok {
+		t.Errorf("Expected key %q to not be cached.", "d")
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	cache := typst.NewMemoryCache(10)
+	testCacheEviction(t, cache)
+
+	if cache.Len() != 2 || cache.Size() != 8 {
+		t.Errorf("Unexpected cache content. Got %d values with %d bytes, want %d values with %d bytes.", cache.Len(), cache.Size(), 2, 8)
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir()
+
+	cache, err := typst.NewDiskCache(dir, 10)
+	if err != nil {
+		t.Fatalf("Failed to create disk cache: %v.", err)
+	}
+	testCacheEviction(t, cache)
+
+	// The content has to persist.
+	cache, err = typst.NewDiskCache(dir, 10)
+	if err != nil {
+		t.Fatalf("Failed to create disk cache: %v.", err)
+	}
+	if cache.Len() != 2 || cache.Size() != 8 {
+		t.Errorf("Unexpected cache content. Got %d values with %d bytes, want %d values with %d bytes.", cache.Len(), cache.Size(), 2, 8)
+	}
+	if value, ok := cache.Get("c"); !ok || string(value) != "cccc" {
+		t.Errorf("Unexpected value for key %q. Got %q, %t.", "c", value, ok)
+	}
+}
@@ -0,0 +1,405 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// CachingCaller wraps another Caller, and caches the output of successful compilations.
+//
+// The cache key is a hash of the Typst version, the normalized compile arguments, the input document and all files inside of the root directory, OptionsCompile.Project and OptionsCompile.Files.
+// Typst is only invoked if there is no cached output for that key.
+// Identical compilations that run at the same time are collapsed into a single invocation.
+// Any warnings of the compilation are cached as well, and passed to the WarningHandler on every hit.
+//
+// The root directory is OptionsCompile.Root, or the directory of the input file.
+// Documents that are read from stdin are only cached if OptionsCompile.Root, OptionsCompile.Project or OptionsCompile.Files is set, as the working directory would have to be walked otherwise.
+// Files inside of the root directory are identified by their path, size and modification time, their content is not read.
+// If the files of the root directory can't be hashed, for example because of missing permissions, the compilation is passed to the wrapped caller without caching.
+//
+// Files are read from the host's file system.
+// If the wrapped caller sees a different file system, like a container, the root directory and input files have to exist on the host at the same path, otherwise the compilation is not cached.
+// Files that are referenced outside of the root directory are not taken into account, so changes to them may not be detected.
+//
+// Output files (see typst.OutputFile) are not cached, and failed compilations are not cached either.
+// All other methods are passed to the wrapped caller directly.
+//
+// Create it with &typst.CachingCaller{Caller: typst.CLI{}, Cache: typst.NewMemoryCache(64 << 20)}, and don't copy it after first use.
+// It is safe for concurrent use.
+type CachingCaller struct {
+	Caller Caller // The caller that is wrapped.
+	Cache  Cache  // The cache to store compilation results in.
+
+	mutex   sync.Mutex
+	version string                   // The detected version string of the wrapped caller. Empty if it hasn't been detected yet.
+	flights map[string]*cachedFlight // Compilations that are currently running, by their key.
+}
+
+// cachedCompilation is the result of a compilation as it is stored in the cache.
+type cachedCompilation struct {
+	Output   []byte
+	Warnings []ErrorDetails
+}
+
+// cachedFlight is a compilation that is currently running, and that other calls with the same key can wait for.
+type cachedFlight struct {
+	done    chan struct{} // Closed once the compilation has finished.
+	result  cachedCompilation
+	err     error
+	aborted bool // Whether the compilation was aborted because of the context of the call that started it.
+}
+
+// Ensure that CachingCaller implements the ContextCaller and QueryCaller interfaces.
+var (
+	_ ContextCaller = &CachingCaller{}
+	_ QueryCaller   = &CachingCaller{}
+)
+
+// versionString returns the version string of the wrapped caller, which is detected once.
+func (c *CachingCaller) versionString(ctx context.Context) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.version == "" {
+		version, err := versionStringWithContext(ctx, c.Caller)
+		if err != nil {
+			return "", fmt.Errorf("failed to detect Typst version: %w", err)
+		}
+		c.version = version
+	}
+
+	return c.version, nil
+}
+
+// writeHashField writes data into h, prefixed by its length so that consecutive fields can't be confused.
+func writeHashField(h hash.Hash, data []byte) {
+	binary.Write(h, binary.LittleEndian, uint64(len(data))) //nolint:errcheck
+	h.Write(data)
+}
+
+// hashFiles writes the names and hashes of all regular files inside of fsys into h.
+func hashFiles(h hash.Hash, fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		fileHash := sha256.Sum256(content)
+		writeHashField(h, []byte(name))
+		writeHashField(h, fileHash[:])
+		return nil
+	})
+}
+
+// hashFileInfos writes the paths, sizes and modification times of all regular files inside of the host directory dir into h.
+// This is much cheaper than hashing their content, and still detects files that have been changed.
+func hashFileInfos(h hash.Hash, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		writeHashField(h, []byte(filepath.ToSlash(name)))
+		binary.Write(h, binary.LittleEndian, info.Size())               //nolint:errcheck
+		binary.Write(h, binary.LittleEndian, info.ModTime().UnixNano()) //nolint:errcheck
+		return nil
+	})
+}
+
+// cacheKey returns the cache key for a compilation.
+// The files of the root directory on the host, or of the project and the in-memory files, are part of the key.
+func cacheKey(version string, args []string, input []byte, root string, project fs.FS, files map[string][]byte) (string, error) {
+	h := sha256.New()
+	writeHashField(h, []byte("go-typst cache v2"))
+	writeHashField(h, []byte(version))
+	for _, arg := range args {
+		writeHashField(h, []byte(arg))
+	}
+	writeHashField(h, input)
+
+	if project != nil {
+		if err := hashFiles(h, project); err != nil {
+			return "", fmt.Errorf("failed to hash files of project: %w", err)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		fileHash := sha256.Sum256(files[name])
+		writeHashField(h, []byte(name))
+		writeHashField(h, fileHash[:])
+	}
+	if project == nil && len(files) == 0 {
+		if err := hashFileInfos(h, root); err != nil {
+			return "", fmt.Errorf("failed to hash files of root directory: %w", err)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// workingDirectory returns the working directory of the wrapped caller on the host.
+// It returns false if it's not known.
+func (c *CachingCaller) workingDirectory() (string, bool) {
+	var cli CLI
+	switch caller := c.Caller.(type) {
+	case CLI:
+		cli = caller
+	case *CLI:
+		cli = *caller
+	default:
+		return "", false
+	}
+
+	if cli.WorkingDirectory == "" {
+		return ".", true
+	}
+	return cli.WorkingDirectory, true
+}
+
+// hostPath returns the given path of the wrapped caller as a path on the host.
+func (c *CachingCaller) hostPath(path string) string {
+	if dir, ok := c.workingDirectory(); ok && !filepath.IsAbs(path) {
+		return filepath.Join(dir, path)
+	}
+	return path
+}
+
+// root returns the root directory of a compilation on the host, whose files are part of the cache key.
+// The input path is "-" if the input is read from stdin.
+// It returns false if the root directory can't be determined, in which case the compilation can't be cached.
+func (c *CachingCaller) root(inputPath string, options *OptionsCompile) (string, bool) {
+	if options.Project != nil || len(options.Files) > 0 {
+		// The project and the in-memory files are part of the key instead.
+		return "", true
+	}
+
+	// Use the same default as Typst.
+	// Typst would use the working directory for stdin, but walking it on every call is too expensive.
+	root := options.Root
+	if root == "" {
+		if inputPath == "-" {
+			return "", false
+		}
+		root = filepath.Dir(inputPath)
+	}
+	root = c.hostPath(root)
+
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		// The directory may only exist for the wrapped caller.
+		return "", false
+	}
+
+	return root, true
+}
+
+// VersionString returns the Typst version as a string.
+func (c *CachingCaller) VersionString() (string, error) {
+	return c.VersionStringWithContext(context.Background())
+}
+
+// VersionStringWithContext returns the Typst version as a string.
+func (c *CachingCaller) VersionStringWithContext(ctx context.Context) (string, error) {
+	return versionStringWithContext(ctx, c.Caller)
+}
+
+// Fonts returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+func (c *CachingCaller) Fonts(options *OptionsFonts) ([]string, error) {
+	return c.FontsWithContext(context.Background(), options)
+}
+
+// FontsWithContext returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+func (c *CachingCaller) FontsWithContext(ctx context.Context, options *OptionsFonts) ([]string, error) {
+	return fontsWithContext(ctx, c.Caller, options)
+}
+
+// Compile takes a Typst document from input, and renders it into the output writer.
+// The options parameter is optional, and can be nil.
+func (c *CachingCaller) Compile(input io.Reader, output io.Writer, options *OptionsCompile) error {
+	return c.CompileWithContext(context.Background(), input, output, options)
+}
+
+// CompileWithContext takes a Typst document from input, and renders it into the output writer.
+// The output is served from the cache if possible.
+// The options parameter is optional, and can be nil.
+func (c *CachingCaller) CompileWithContext(ctx context.Context, input io.Reader, output io.Writer, options *OptionsCompile) error {
+	if _, ok := output.(OutputFile); ok {
+		return compileWithContext(ctx, c.Caller, input, output, options)
+	}
+	if options == nil {
+		options = new(OptionsCompile)
+	}
+
+	version, err := c.versionString(ctx)
+	if err != nil {
+		return err
+	}
+
+	inputPath := "-"
+	if file, ok := input.(InputFile); ok {
+		inputPath = string(file)
+	}
+
+	root, ok := c.root(inputPath, options)
+	if !ok {
+		return compileWithContext(ctx, c.Caller, input, output, options)
+	}
+
+	var content []byte
+	if inputPath != "-" {
+		if data, ok := options.Files[inputPath]; ok {
+			content = data
+		} else if options.Project != nil {
+			content, err = fs.ReadFile(options.Project, inputPath)
+		} else {
+			content, err = os.ReadFile(c.hostPath(inputPath))
+		}
+		if err != nil {
+			// The file may only exist for the wrapped caller, so we can't hash it.
+			return compileWithContext(ctx, c.Caller, input, output, options)
+		}
+	} else if content, err = io.ReadAll(input); err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+
+	key, err := cacheKey(version, options.args("c", inputPath, "-", latestVersion.Capabilities()), content, root, options.Project, options.Files)
+	if err != nil {
+		// The files may still be readable for the wrapped caller, so compile without caching.
+		if _, ok := input.(InputFile); !ok {
+			input = bytes.NewReader(content)
+		}
+		return compileWithContext(ctx, c.Caller, input, output, options)
+	}
+
+	for {
+		if data, ok := c.Cache.Get(key); ok {
+			var result cachedCompilation
+			if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&result); err == nil {
+				return c.deliver(result, output, options)
+			}
+		}
+
+		c.mutex.Lock()
+		if c.flights == nil {
+			c.flights = make(map[string]*cachedFlight)
+		}
+		if flight, ok := c.flights[key]; ok {
+			c.mutex.Unlock()
+
+			select {
+			case <-flight.done:
+			case <-ctx.Done():
+				return fmt.Errorf("typst invocation was aborted: %w", ctx.Err())
+			}
+			if flight.aborted {
+				// Try again, this call may start the compilation itself.
+				continue
+			}
+			if flight.err != nil {
+				return flight.err
+			}
+			return c.deliver(flight.result, output, options)
+		}
+
+		flight := &cachedFlight{done: make(chan struct{})}
+		c.flights[key] = flight
+		c.mutex.Unlock()
+
+		c.compile(ctx, key, flight, input, content, options)
+
+		c.mutex.Lock()
+		delete(c.flights, key)
+		c.mutex.Unlock()
+		close(flight.done)
+
+		if flight.err != nil {
+			return flight.err
+		}
+		return c.deliver(flight.result, output, options)
+	}
+}
+
+// compile invokes the wrapped caller, and stores the result in flight and in the cache.
+func (c *CachingCaller) compile(ctx context.Context, key string, flight *cachedFlight, input io.Reader, content []byte, options *OptionsCompile) {
+	if _, ok := input.(InputFile); !ok {
+		input = bytes.NewReader(content)
+	}
+
+	// Collect the warnings, so they can be delivered on every hit.
+	optionsCopy := *options
+	optionsCopy.WarningHandler = func(warnings []ErrorDetails) {
+		flight.result.Warnings = append(flight.result.Warnings, warnings...)
+	}
+
+	var buffer bytes.Buffer
+	if err := compileWithContext(ctx, c.Caller, input, &buffer, &optionsCopy); err != nil {
+		flight.err = err
+		flight.aborted = ctx.Err() != nil
+		return
+	}
+	flight.result.Output = buffer.Bytes()
+
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(flight.result); err == nil {
+		c.Cache.Put(key, data.Bytes())
+	}
+}
+
+// deliver writes a compilation result into output, and passes its warnings to the WarningHandler in options.
+func (c *CachingCaller) deliver(result cachedCompilation, output io.Writer, options *OptionsCompile) error {
+	if options.WarningHandler != nil && len(result.Warnings) > 0 {
+		options.WarningHandler(result.Warnings)
+	}
+
+	if _, err := output.Write(result.Output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}
+
+// Query takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+func (c *CachingCaller) Query(input io.Reader, result any, options *OptionsQuery) error {
+	return c.QueryWithContext(context.Background(), input, result, options)
+}
+
+// QueryWithContext takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+func (c *CachingCaller) QueryWithContext(ctx context.Context, input io.Reader, result any, options *OptionsQuery) error {
+	return queryWithContext(ctx, c.Caller, input, result, options)
+}
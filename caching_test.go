@@ -0,0 +1,341 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Dadido3/go-typst"
+)
+
+// fakeCaller is a Caller that doesn't invoke Typst, and counts its compilations.
+type fakeCaller struct {
+	compilations atomic.Int32
+	running      atomic.Int32 // Number of compilations that are currently running.
+	maxRunning   atomic.Int32 // Highest number of compilations that ran at the same time.
+	jobs         atomic.Int32 // OptionsCompile.Jobs of the last compilation.
+	delay        time.Duration
+	warnings     []typst.ErrorDetails
+	err          error
+}
+
+func (f *fakeCaller) VersionString() (string, error) {
+	return f.VersionStringWithContext(context.Background())
+}
+
+func (f *fakeCaller) VersionStringWithContext(ctx context.Context) (string, error) {
+	return "typst 0.14.0 (fake)", nil
+}
+
+func (f *fakeCaller) Fonts(options *typst.OptionsFonts) ([]string, error) {
+	return f.FontsWithContext(context.Background(), options)
+}
+
+func (f *fakeCaller) FontsWithContext(ctx context.Context, options *typst.OptionsFonts) ([]string, error) {
+	return []string{"Fake Sans"}, nil
+}
+
+func (f *fakeCaller) Compile(input io.Reader, output io.Writer, options *typst.OptionsCompile) error {
+	return f.CompileWithContext(context.Background(), input, output, options)
+}
+
+func (f *fakeCaller) CompileWithContext(ctx context.Context, input io.Reader, output io.Writer, options *typst.OptionsCompile) error {
+	f.compilations.Add(1)
+	if options != nil {
+		f.jobs.Store(int32(options.Jobs))
+	}
+
+	running := f.running.Add(1)
+	defer f.running.Add(-1)
+	for maxRunning := f.maxRunning.Load(); running > maxRunning && !f.maxRunning.CompareAndSwap(maxRunning, running); maxRunning = f.maxRunning.Load() {
+	}
+
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if f.err != nil {
+		return f.err
+	}
+
+	var content []byte
+	var err error
+	if file, ok := input.(typst.InputFile); ok {
+		content, err = os.ReadFile(string(file))
+	} else {
+		content, err = io.ReadAll(input)
+	}
+	if err != nil {
+		return err
+	}
+	if options != nil && options.WarningHandler != nil && len(f.warnings) > 0 {
+		options.WarningHandler(f.warnings)
+	}
+
+	_, err = output.Write(append([]byte("compiled: "), content...))
+	return err
+}
+
+func (f *fakeCaller) Query(input io.Reader, result any, options *typst.OptionsQuery) error {
+	return f.QueryWithContext(context.Background(), input, result, options)
+}
+
+func (f *fakeCaller) QueryWithContext(ctx context.Context, input io.Reader, result any, options *typst.OptionsQuery) error {
+	return nil
+}
+
+// plainCaller is a Caller that only implements the methods of the Caller interface.
+type plainCaller struct{}
+
+func (plainCaller) VersionString() (string, error) {
+	return "typst 0.14.0 (plain)", nil
+}
+
+func (plainCaller) Fonts(options *typst.OptionsFonts) ([]string, error) {
+	return []string{"Plain Sans"}, nil
+}
+
+func (plainCaller) Compile(input io.Reader, output io.Writer, options *typst.OptionsCompile) error {
+	_, err := io.Copy(output, input)
+	return err
+}
+
+func TestCachingCaller_PlainCaller(t *testing.T) {
+	caller := &typst.CachingCaller{Caller: plainCaller{}, Cache: typst.NewMemoryCache(1 << 20)}
+
+	var w bytes.Buffer
+	if err := caller.Compile(bytes.NewBufferString("Hello"), &w, &typst.OptionsCompile{Root: t.TempDir()}); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+	if w.String() != "Hello" {
+		t.Errorf("Unexpected output %q.", w.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := caller.CompileWithContext(ctx, bytes.NewBufferString("Hello"), &w, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Unexpected error %v, want %v.", err, context.Canceled)
+	}
+
+	if err := caller.Query(bytes.NewBufferString("Hello"), new(any), &typst.OptionsQuery{Selector: "heading"}); !errors.Is(err, typst.ErrQueryUnsupported) {
+		t.Errorf("Unexpected error %v, want %v.", err, typst.ErrQueryUnsupported)
+	}
+}
+
+func TestCachingCaller(t *testing.T) {
+	fake := &fakeCaller{warnings: []typst.ErrorDetails{{Message: "warning: unknown font family: foo", Severity: typst.DiagnosticSeverityWarning}}}
+	caller := &typst.CachingCaller{Caller: fake, Cache: typst.NewMemoryCache(1 << 20)}
+
+	// Documents from stdin need a root directory to be cached.
+	root := t.TempDir()
+
+	compile := func(input string, options *typst.OptionsCompile) (string, []typst.ErrorDetails) {
+		var warnings []typst.ErrorDetails
+		if options == nil {
+			options = new(typst.OptionsCompile)
+		}
+		options.Root = root
+		options.WarningHandler = func(w []typst.ErrorDetails) { warnings = append(warnings, w...) }
+
+		var w bytes.Buffer
+		if err := caller.Compile(bytes.NewBufferString(input), &w, options); err != nil {
+			t.Fatalf("Failed to compile document: %v.", err)
+		}
+		return w.String(), warnings
+	}
+
+	for range 3 {
+		output, warnings := compile("Hello", nil)
+		if output != "compiled: Hello" {
+			t.Errorf("Unexpected output %q.", output)
+		}
+		if len(warnings) != 1 {
+			t.Errorf("Unexpected number of warnings. Got %d, want %d.", len(warnings), 1)
+		}
+	}
+	if n := fake.compilations.Load(); n != 1 {
+		t.Errorf("Unexpected number of compilations. Got %d, want %d.", n, 1)
+	}
+
+	// Different input or options must not hit the cache.
+	compile("World", nil)
+	compile("Hello", &typst.OptionsCompile{Format: typst.OutputFormatSVG})
+	compile("Hello", &typst.OptionsCompile{Input: map[string]string{"foo": "bar"}})
+	if n := fake.compilations.Load(); n != 4 {
+		t.Errorf("Unexpected number of compilations. Got %d, want %d.", n, 4)
+	}
+}
+
+func TestCachingCaller_Root(t *testing.T) {
+	fake := &fakeCaller{}
+	caller := &typst.CachingCaller{Caller: fake, Cache: typst.NewMemoryCache(1 << 20)}
+
+	root := t.TempDir()
+	dataPath := filepath.Join(root, "data.json")
+	compile := func() {
+		var w bytes.Buffer
+		if err := caller.Compile(bytes.NewBufferString(`#json("data.json")`), &w, &typst.OptionsCompile{Root: root}); err != nil {
+			t.Fatalf("Failed to compile document: %v.", err)
+		}
+	}
+
+	if err := os.WriteFile(dataPath, []byte(`{"a": 1}`), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v.", err)
+	}
+	compile()
+	compile()
+
+	// Changing a referenced file must invalidate the cached output.
+	if err := os.WriteFile(dataPath, []byte(`{"a": 2}`), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v.", err)
+	}
+	compile()
+
+	if n := fake.compilations.Load(); n != 2 {
+		t.Errorf("Unexpected number of compilations. Got %d, want %d.", n, 2)
+	}
+}
+
+func TestCachingCaller_DefaultRoot(t *testing.T) {
+	fake := &fakeCaller{}
+	caller := &typst.CachingCaller{Caller: fake, Cache: typst.NewMemoryCache(1 << 20)}
+
+	// Without Root, Typst uses the directory of the input file.
+	dir := t.TempDir()
+	inputPath, dataPath := filepath.Join(dir, "main.typ"), filepath.Join(dir, "data.json")
+	if err := os.WriteFile(inputPath, []byte(`#json("data.json")`), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v.", err)
+	}
+	compile := func() {
+		var w bytes.Buffer
+		if err := caller.Compile(typst.InputFile(inputPath), &w, nil); err != nil {
+			t.Fatalf("Failed to compile document: %v.", err)
+		}
+	}
+
+	if err := os.WriteFile(dataPath, []byte(`{"a": 1}`), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v.", err)
+	}
+	compile()
+	compile()
+
+	if err := os.WriteFile(dataPath, []byte(`{"a": 22}`), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v.", err)
+	}
+	compile()
+
+	if n := fake.compilations.Load(); n != 2 {
+		t.Errorf("Unexpected number of compilations. Got %d, want %d.", n, 2)
+	}
+}
+
+func TestCachingCaller_UnknownRoot(t *testing.T) {
+	fake := &fakeCaller{}
+	caller := &typst.CachingCaller{Caller: fake, Cache: typst.NewMemoryCache(1 << 20)}
+
+	// Documents from stdin without Root are not cached, as their root would be the working directory.
+	// The same applies to a Root that doesn't exist on the host.
+	for _, options := range []*typst.OptionsCompile{nil, {Root: "/does-not-exist"}} {
+		for range 2 {
+			var w bytes.Buffer
+			if err := caller.Compile(bytes.NewBufferString("Hello"), &w, options); err != nil {
+				t.Fatalf("Failed to compile document: %v.", err)
+			}
+		}
+	}
+
+	if n := fake.compilations.Load(); n != 4 {
+		t.Errorf("Unexpected number of compilations. Got %d, want %d.", n, 4)
+	}
+}
+
+func TestCachingCaller_UnreadableRoot(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("Permissions are not enforced for root.")
+	}
+
+	fake := &fakeCaller{}
+	caller := &typst.CachingCaller{Caller: fake, Cache: typst.NewMemoryCache(1 << 20)}
+
+	// A directory that can't be walked must not make the compilation fail.
+	root := t.TempDir()
+	unreadable := filepath.Join(root, "private")
+	if err := os.Mkdir(unreadable, 0000); err != nil {
+		t.Fatalf("Failed to create directory: %v.", err)
+	}
+	t.Cleanup(func() { os.Chmod(unreadable, 0755) })
+
+	for range 2 {
+		var w bytes.Buffer
+		if err := caller.Compile(bytes.NewBufferString("Hello"), &w, &typst.OptionsCompile{Root: root}); err != nil {
+			t.Fatalf("Failed to compile document: %v.", err)
+		}
+		if w.String() != "compiled: Hello" {
+			t.Errorf("Unexpected output %q.", w.String())
+		}
+	}
+
+	if n := fake.compilations.Load(); n != 2 {
+		t.Errorf("Unexpected number of compilations. Got %d, want %d.", n, 2)
+	}
+}
+
+func TestCachingCaller_Concurrent(t *testing.T) {
+	fake := &fakeCaller{delay: 100 * time.Millisecond}
+	caller := &typst.CachingCaller{Caller: fake, Cache: typst.NewMemoryCache(1 << 20)}
+
+	options := &typst.OptionsCompile{Root: t.TempDir()}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var w bytes.Buffer
+			if err := caller.Compile(bytes.NewBufferString("Hello"), &w, options); err != nil {
+				t.Errorf("Failed to compile document: %v.", err)
+			}
+			if w.String() != "compiled: Hello" {
+				t.Errorf("Unexpected output %q.", w.String())
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := fake.compilations.Load(); n != 1 {
+		t.Errorf("Unexpected number of compilations. Got %d, want %d.", n, 1)
+	}
+}
+
+func TestCachingCaller_Error(t *testing.T) {
+	fake := &fakeCaller{err: &typst.Error{Raw: "error: something went wrong"}}
+	caller := &typst.CachingCaller{Caller: fake, Cache: typst.NewMemoryCache(1 << 20)}
+
+	options := &typst.OptionsCompile{Root: t.TempDir()}
+
+	for range 2 {
+		var w bytes.Buffer
+		if err := caller.Compile(bytes.NewBufferString("Hello"), &w, options); err == nil {
+			t.Fatalf("Expected error, but got nil.")
+		}
+	}
+
+	// Errors must not be cached.
+	if n := fake.compilations.Load(); n != 2 {
+		t.Errorf("Unexpected number of compilations. Got %d, want %d.", n, 2)
+	}
+}
@@ -5,22 +5,118 @@
 
 package typst
 
-import "io"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
 
-// TODO: Add WASM caller
-
-// TODO: Add special type "Filename" (or similar) that implements a io.Reader/io.Writer that can be plugged into the input and output parameters of the Compile method to signal the use of input/output files instead of readers/writers
+// ErrQueryUnsupported is returned when a query is made through a caller that doesn't implement QueryCaller.
+var ErrQueryUnsupported = errors.New("the caller doesn't support queries")
 
 // Caller contains all Typst commands that are supported by this library.
+//
+// Callers can implement ContextCaller and QueryCaller for additional functionality.
+// All callers of this library implement both.
 type Caller interface {
 	// VersionString returns the Typst version as a string.
 	VersionString() (string, error)
 
 	// Fonts returns all fonts that are available to Typst.
 	// The options parameter is optional, and can be nil.
+	// The returned lines can be parsed with typst.ParseFonts, or use typst.FontFamilies directly.
 	Fonts(options *OptionsFonts) ([]string, error)
 
 	// Compile takes a Typst document from the supplied input reader, and renders it into the output writer.
+	// Use typst.InputFile and typst.OutputFile as input and output to make Typst read and write files directly.
 	// The options parameter is optional, and can be nil.
 	Compile(input io.Reader, output io.Writer, options *OptionsCompile) error
 }
+
+// ContextCaller is a Caller whose invocations can be aborted with a context.
+type ContextCaller interface {
+	Caller
+
+	// VersionStringWithContext returns the Typst version as a string.
+	// Once ctx is done, the invocation is aborted and an error wrapping ctx.Err() is returned.
+	VersionStringWithContext(ctx context.Context) (string, error)
+
+	// FontsWithContext returns all fonts that are available to Typst.
+	// The options parameter is optional, and can be nil.
+	// Once ctx is done, the invocation is aborted and an error wrapping ctx.Err() is returned.
+	FontsWithContext(ctx context.Context, options *OptionsFonts) ([]string, error)
+
+	// CompileWithContext takes a Typst document from the supplied input reader, and renders it into the output writer.
+	// The options parameter is optional, and can be nil.
+	// Once ctx is done, the invocation is aborted and an error wrapping ctx.Err() is returned.
+	CompileWithContext(ctx context.Context, input io.Reader, output io.Writer, options *OptionsCompile) error
+}
+
+// QueryCaller is a Caller that supports Typst's query command.
+type QueryCaller interface {
+	Caller
+
+	// Query takes a Typst document from the supplied input reader, and retrieves the elements that match the selector in options.
+	// The JSON result is decoded into result, which works the same as json.Unmarshal.
+	// The options parameter is mandatory, as it contains the selector.
+	Query(input io.Reader, result any, options *OptionsQuery) error
+
+	// QueryWithContext takes a Typst document from the supplied input reader, and retrieves the elements that match the selector in options.
+	// The JSON result is decoded into result, which works the same as json.Unmarshal.
+	// The options parameter is mandatory, as it contains the selector.
+	// Once ctx is done, the invocation is aborted and an error wrapping ctx.Err() is returned.
+	QueryWithContext(ctx context.Context, input io.Reader, result any, options *OptionsQuery) error
+}
+
+// checkContext returns an error wrapping ctx.Err() if ctx is already done.
+// It's used for callers that don't implement ContextCaller, which can't be aborted once they have started.
+func checkContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("typst invocation was aborted: %w", err)
+	}
+	return nil
+}
+
+// versionStringWithContext calls VersionStringWithContext if caller implements ContextCaller, and VersionString otherwise.
+func versionStringWithContext(ctx context.Context, caller Caller) (string, error) {
+	if c, ok := caller.(ContextCaller); ok {
+		return c.VersionStringWithContext(ctx)
+	}
+	if err := checkContext(ctx); err != nil {
+		return "", err
+	}
+	return caller.VersionString()
+}
+
+// fontsWithContext calls FontsWithContext if caller implements ContextCaller, and Fonts otherwise.
+func fontsWithContext(ctx context.Context, caller Caller, options *OptionsFonts) ([]string, error) {
+	if c, ok := caller.(ContextCaller); ok {
+		return c.FontsWithContext(ctx, options)
+	}
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	return caller.Fonts(options)
+}
+
+// compileWithContext calls CompileWithContext if caller implements ContextCaller, and Compile otherwise.
+func compileWithContext(ctx context.Context, caller Caller, input io.Reader, output io.Writer, options *OptionsCompile) error {
+	if c, ok := caller.(ContextCaller); ok {
+		return c.CompileWithContext(ctx, input, output, options)
+	}
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	return caller.Compile(input, output, options)
+}
+
+// queryWithContext calls QueryWithContext if caller implements QueryCaller.
+// It returns ErrQueryUnsupported otherwise.
+func queryWithContext(ctx context.Context, caller Caller, input io.Reader, result any, options *OptionsQuery) error {
+	c, ok := caller.(QueryCaller)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrQueryUnsupported, caller)
+	}
+	return c.QueryWithContext(ctx, input, result, options)
+}
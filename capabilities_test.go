@@ -0,0 +1,74 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+//go:build unix
+
+package typst_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Dadido3/go-typst"
+)
+
+func TestCompile_HTMLFeatureFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    bool
+	}{
+		{"typst-html-0.12", "typst 0.12.0 (737895d7)", false},
+		{"typst-html-0.14", "typst 0.14.0 (b790c6d5)", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logPath := fakeRuntime(t, tt.name, `echo "`+tt.version+`"`)
+
+			typstCaller := typst.CLI{ExecutablePath: tt.name}
+			if err := typstCaller.Compile(strings.NewReader("Hello"), &bytes.Buffer{}, &typst.OptionsCompile{Format: typst.OutputFormatHTML}); err != nil {
+				t.Fatalf("Failed to compile document: %v.", err)
+			}
+
+			calls := readCalls(t, logPath)
+			if len(calls) != 2 || calls[0] != "--version" {
+				t.Fatalf("Expected a version check followed by a compilation, got %q.", calls)
+			}
+			if got := slices.Contains(strings.Fields(calls[1]), "--features"); got != tt.want {
+				t.Errorf("Got --features in %q = %v, want %v.", calls[1], got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_HTMLFeatureFlagReplacedExecutable(t *testing.T) {
+	logPath := fakeRuntime(t, "typst-html-replaced", `echo "typst 0.12.0 (737895d7)"`)
+	typstCaller := typst.CLI{ExecutablePath: "typst-html-replaced"}
+
+	compile := func() []string {
+		if err := typstCaller.Compile(strings.NewReader("Hello"), &bytes.Buffer{}, &typst.OptionsCompile{Format: typst.OutputFormatHTML}); err != nil {
+			t.Fatalf("Failed to compile document: %v.", err)
+		}
+		calls := readCalls(t, logPath)
+		return strings.Fields(calls[len(calls)-1])
+	}
+
+	if slices.Contains(compile(), "--features") {
+		t.Errorf("Typst 0.12.0 must not be invoked with --features.")
+	}
+
+	// Replace the executable in place, the new version has to be detected.
+	content := "#!/bin/sh\necho \"$@\" >> " + logPath + "\necho \"typst 0.14.0 (b790c6d5)\"\n"
+	if err := os.WriteFile(filepath.Join(filepath.Dir(logPath), "typst-html-replaced"), []byte(content), 0755); err != nil {
+		t.Fatalf("Failed to replace fake executable: %v.", err)
+	}
+	if !slices.Contains(compile(), "--features") {
+		t.Errorf("Typst 0.14.0 must be invoked with --features.")
+	}
+}
@@ -6,127 +6,122 @@
 package typst
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"os/exec"
 )
 
 // CLI allows you to invoke commands on a native Typst executable.
 type CLI struct {
-	ExecutablePath   string // The Typst executable path can be overridden here. Otherwise the default path will be used.
-	WorkingDirectory string // The path where the Typst executable is run in. When left empty, the Typst executable will be run in the process's current directory.
+	ExecutablePath   string       // The Typst executable path can be overridden here. Otherwise the default path will be used.
+	WorkingDirectory string       // The path where the Typst executable is run in. When left empty, the Typst executable will be run in the process's current directory.
+	Environment      *Environment // Optional environment variables of Typst. When nil, the environment of the current process is inherited.
+	Hooks            Hooks        // Optional hooks that are called before and after every invocation of Typst, see typst.SlogHooks.
 }
 
-// Ensure that CLI implements the Caller interface.
-var _ Caller = CLI{}
+// Ensure that CLI implements the ContextCaller and QueryCaller interfaces.
+var (
+	_ ContextCaller = CLI{}
+	_ QueryCaller   = CLI{}
+)
 
-// VersionString returns the Typst version as a string.
-func (c CLI) VersionString() (string, error) {
+// run implements the runner interface.
+func (c CLI) run(ctx context.Context, inv *invocation) error {
 	// Get path of executable.
 	execPath := ExecutablePath
 	if c.ExecutablePath != "" {
 		execPath = c.ExecutablePath
 	}
 	if execPath == "" {
-		return "", fmt.Errorf("not supported on this platform")
+		return fmt.Errorf("not supported on this platform")
 	}
 
-	cmd := exec.Command(execPath, "--version")
-	cmd.Dir = c.WorkingDirectory // This doesn't do anything, but we will do it anyways for consistency.
-
-	var output, errBuffer bytes.Buffer
-	cmd.Stdout = &output
-	cmd.Stderr = &errBuffer
+	cmd := commandContext(ctx, nil, execPath, inv.args...)
+	cmd.Dir = c.WorkingDirectory
+	cmd.Env = c.Environment.environ()
+	cmd.Stdin = inv.stdin
+	cmd.Stdout = inv.stdout
+	cmd.Stderr = inv.stderr
 
-	if err := cmd.Run(); err != nil {
-		switch err := err.(type) {
-		case *exec.ExitError:
-			return "", ParseStderr(errBuffer.String(), err)
-		default:
-			return "", err
-		}
-	}
+	return cmd.Run()
+}
 
-	return output.String(), nil
+// newWorkspace implements the runner interface.
+func (c CLI) newWorkspace(ctx context.Context, parent string) (*workspace, error) {
+	return newHostWorkspace(parent)
 }
 
-// Fonts returns all fonts that are available to Typst.
-// The options parameter is optional, and can be nil.
-func (c CLI) Fonts(options *OptionsFonts) ([]string, error) {
-	// Get path of executable.
-	execPath := ExecutablePath
-	if c.ExecutablePath != "" {
-		execPath = c.ExecutablePath
-	}
-	if execPath == "" {
-		return nil, fmt.Errorf("not supported on this platform")
-	}
+// kind implements the runner interface.
+func (c CLI) kind() CallerKind {
+	return CallerKindCLI
+}
 
-	if options == nil {
-		options = new(OptionsFonts)
-	}
+// hooks implements the runner interface.
+func (c CLI) hooks() Hooks {
+	return c.Hooks
+}
 
-	cmd := exec.Command(execPath, options.Args()...)
-	cmd.Dir = c.WorkingDirectory
+// VersionString returns the Typst version as a string.
+func (c CLI) VersionString() (string, error) {
+	return c.VersionStringWithContext(context.Background())
+}
 
-	var output, errBuffer bytes.Buffer
-	cmd.Stdout = &output
-	cmd.Stderr = &errBuffer
-
-	if err := cmd.Run(); err != nil {
-		switch err := err.(type) {
-		case *exec.ExitError:
-			return nil, ParseStderr(errBuffer.String(), err)
-		default:
-			return nil, err
-		}
-	}
+// VersionStringWithContext returns the Typst version as a string.
+// The Typst process is terminated once ctx is done.
+func (c CLI) VersionStringWithContext(ctx context.Context) (string, error) {
+	return versionString(ctx, c)
+}
 
-	var result []string
-	scanner := bufio.NewScanner(&output)
-	for scanner.Scan() {
-		result = append(result, scanner.Text())
-	}
+// Fonts returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+func (c CLI) Fonts(options *OptionsFonts) ([]string, error) {
+	return c.FontsWithContext(context.Background(), options)
+}
 
-	return result, nil
+// FontsWithContext returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+// The Typst process is terminated once ctx is done.
+func (c CLI) FontsWithContext(ctx context.Context, options *OptionsFonts) ([]string, error) {
+	return fonts(ctx, c, options)
 }
 
 // Compile takes a Typst document from input, and renders it into the output writer.
 // The options parameter is optional, and can be nil.
 func (c CLI) Compile(input io.Reader, output io.Writer, options *OptionsCompile) error {
-	// Get path of executable.
-	execPath := ExecutablePath
-	if c.ExecutablePath != "" {
-		execPath = c.ExecutablePath
-	}
-	if execPath == "" {
-		return fmt.Errorf("not supported on this platform")
-	}
+	return c.CompileWithContext(context.Background(), input, output, options)
+}
 
-	if options == nil {
-		options = new(OptionsCompile)
-	}
+// CompileWithContext takes a Typst document from input, and renders it into the output writer.
+// The options parameter is optional, and can be nil.
+// The Typst process is terminated once ctx is done.
+func (c CLI) CompileWithContext(ctx context.Context, input io.Reader, output io.Writer, options *OptionsCompile) error {
+	return compile(ctx, c, input, output, options)
+}
 
-	cmd := exec.Command(execPath, options.Args()...)
-	cmd.Dir = c.WorkingDirectory
-	cmd.Stdin = input
-	cmd.Stdout = output
-
-	errBuffer := bytes.Buffer{}
-	cmd.Stderr = &errBuffer
-
-	if err := cmd.Run(); err != nil {
-		switch err := err.(type) {
-		case *exec.ExitError:
-			return ParseStderr(errBuffer.String(), err)
-		default:
-			return err
-		}
-	}
+// Query takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+func (c CLI) Query(input io.Reader, result any, options *OptionsQuery) error {
+	return c.QueryWithContext(context.Background(), input, result, options)
+}
+
+// QueryWithContext takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+// The Typst process is terminated once ctx is done.
+func (c CLI) QueryWithContext(ctx context.Context, input io.Reader, result any, options *OptionsQuery) error {
+	return query(ctx, c, input, result, options)
+}
 
-	return nil
+// Watch starts Typst in watch mode, which recompiles the document at the input path whenever it or any of its dependencies change.
+// The result is written to the output path, which may be left empty to let Typst derive it from the input path.
+// The paths are relative to the working directory.
+// The options parameter is optional, and can be nil.
+//
+// The returned Watcher reports all compilations until ctx is done, or until it is closed.
+func (c CLI) Watch(ctx context.Context, input, output string, options *OptionsCompile) (*Watcher, error) {
+	return watch(ctx, c, input, output, options)
 }
 
 // Deprecated: You should use typst.InjectValues in combination with the normal Compile method instead.
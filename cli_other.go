@@ -7,6 +7,13 @@
 
 package typst
 
+import "os"
+
 // The path to the Typst executable.
 // We leave that empty as we don't support this platform for now.
 var ExecutablePath = ""
+
+// terminateProcess asks the given process to terminate.
+func terminateProcess(p *os.Process) error {
+	return p.Kill()
+}
@@ -7,11 +7,14 @@ package typst_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"image"
 	_ "image/png"
 	"path/filepath"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/Dadido3/go-typst"
 )
@@ -118,3 +121,88 @@ func TestCLI_CompileWithWorkingDir(t *testing.T) {
 		t.Errorf("No output was written.")
 	}
 }
+
+// Test that a long running compilation is aborted once the context times out.
+func TestCLI_CompileWithContextTimeout(t *testing.T) {
+	cli := typst.CLI{}
+
+	r := bytes.NewBufferString(`#for i in range(1000000000) {}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	var w bytes.Buffer
+	err := cli.CompileWithContext(ctx, r, &w, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected error wrapping %v, got %v.", context.DeadlineExceeded, err)
+	}
+	var errTypst *typst.Error
+	if errors.As(err, &errTypst) {
+		t.Errorf("Expected error not to be of type %T.", errTypst)
+	}
+}
+
+// Test that nothing is run when the context is already canceled.
+func TestCLI_VersionStringWithContextCanceled(t *testing.T) {
+	cli := typst.CLI{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := cli.VersionStringWithContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected error wrapping %v, got %v.", context.Canceled, err)
+	}
+}
+
+func TestCLI_Query(t *testing.T) {
+	cli := typst.CLI{}
+
+	r := bytes.NewBufferString(`#metadata((name: "go-typst", count: 3)) <info>
+= First
+== Second`)
+
+	var info struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+	if err := cli.Query(r, &info, &typst.OptionsQuery{Selector: "<info>", Field: "value", One: true}); err != nil {
+		t.Fatalf("Failed to query document: %v.", err)
+	}
+	if info.Name != "go-typst" || info.Count != 3 {
+		t.Errorf("Unexpected query result %+v.", info)
+	}
+}
+
+func TestCLI_QueryHeadings(t *testing.T) {
+	cli := typst.CLI{}
+
+	r := bytes.NewBufferString(`= First
+== Second
+= Third`)
+
+	var headings []struct {
+		Level int `json:"level"`
+	}
+	if err := cli.Query(r, &headings, &typst.OptionsQuery{Selector: "heading"}); err != nil {
+		t.Fatalf("Failed to query document: %v.", err)
+	}
+	if len(headings) != 3 {
+		t.Fatalf("Unexpected number of headings. Got %d, want %d.", len(headings), 3)
+	}
+	if headings[1].Level != 2 {
+		t.Errorf("Unexpected heading level. Got %d, want %d.", headings[1].Level, 2)
+	}
+}
+
+func TestCLI_QueryError(t *testing.T) {
+	cli := typst.CLI{}
+
+	r := bytes.NewBufferString(`#assert(1 < 1, message: "Test")`)
+
+	var result any
+	err := cli.Query(r, &result, &typst.OptionsQuery{Selector: "heading"})
+	var errTypst *typst.Error
+	if !errors.As(err, &errTypst) {
+		t.Fatalf("Expected error type %T, got %T: %v.", errTypst, err, err)
+	}
+}
@@ -7,5 +7,15 @@
 
 package typst
 
+import (
+	"os"
+	"syscall"
+)
+
 // The path to the Typst executable.
 var ExecutablePath = "typst"
+
+// terminateProcess asks the given process to terminate.
+func terminateProcess(p *os.Process) error {
+	return p.Signal(syscall.SIGTERM)
+}
@@ -7,9 +7,17 @@
 
 package typst
 
+import "os"
+
 // The path to the Typst executable.
 // We assume the executable is in the current working directory.
 //var ExecutablePath = "." + string(filepath.Separator) + filepath.Join("typst.exe")
 
 // The path to the Typst executable.
 var ExecutablePath = "typst.exe"
+
+// terminateProcess asks the given process to terminate.
+// Windows doesn't support sending interrupt signals, so the process is killed right away.
+func terminateProcess(p *os.Process) error {
+	return p.Kill()
+}
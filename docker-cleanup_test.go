@@ -0,0 +1,110 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+//go:build unix
+
+package typst_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dadido3/go-typst"
+)
+
+func TestDocker_RemoveContainer(t *testing.T) {
+	logPath := fakeRuntime(t, "docker-cleanup", `[ "$1" = run ] && exec sleep 10`)
+
+	typstCaller := typst.Docker{Executable: "docker-cleanup"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	if _, err := typstCaller.VersionStringWithContext(ctx); err == nil {
+		t.Fatalf("Expected error, but got nil.")
+	}
+
+	calls := readCalls(t, logPath)
+	if len(calls) != 2 {
+		t.Fatalf("Unexpected number of invocations. Got %d, want %d.", len(calls), 2)
+	}
+	for _, want := range []string{" --rm ", " --label " + typst.DockerLabel + "=run "} {
+		if !strings.Contains(calls[0], want) {
+			t.Errorf("Invocation %q doesn't contain %q.", calls[0], want)
+		}
+	}
+
+	// The aborted container has to be removed by its name.
+	_, rest, _ := strings.Cut(calls[0], " --name ")
+	name, _, _ := strings.Cut(rest, " ")
+	if want := "rm --force " + name; !strings.HasPrefix(name, "go-typst-") || calls[1] != want {
+		t.Errorf("Unexpected invocation %q, want %q.", calls[1], want)
+	}
+}
+
+// panickingWriter is an io.Writer that panics on every write.
+type panickingWriter struct{}
+
+func (panickingWriter) Write(p []byte) (int, error) {
+	panic("write failed")
+}
+
+func TestDocker_RemoveContainerOnPanic(t *testing.T) {
+	logPath := fakeRuntime(t, "docker-panic", `[ "$1" = run ] && echo "output"`)
+
+	typstCaller := typst.Docker{Executable: "docker-panic"}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "write failed" {
+				t.Errorf("Unexpected panic value %v, want %q.", r, "write failed")
+			}
+		}()
+		typstCaller.Compile(strings.NewReader("Hello"), panickingWriter{}, nil) //nolint:errcheck
+	}()
+
+	calls := readCalls(t, logPath)
+	if len(calls) < 2 {
+		t.Fatalf("Unexpected number of invocations. Got %d, want at least %d: %q.", len(calls), 2, calls)
+	}
+
+	// The container has to be removed by its name, even though the invocation has panicked.
+	_, rest, _ := strings.Cut(calls[0], " --name ")
+	name, _, _ := strings.Cut(rest, " ")
+	if want := "rm --force " + name; !strings.HasPrefix(name, "go-typst-") || !slices.Contains(calls[1:], want) {
+		t.Errorf("Unexpected invocations %q, want %q.", calls[1:], want)
+	}
+}
+
+func TestDocker_SweepContainers(t *testing.T) {
+	const layout = "2006-01-02 15:04:05 -0700 MST"
+	now, old := time.Now().Format(layout), time.Now().Add(-time.Hour).Format(layout)
+
+	logPath := fakeRuntime(t, "docker-sweep", `[ "$1" != ps ] || printf 'exited\texited\t`+now+`\ndead\tdead\t`+now+`\nold\trunning\t`+old+`\nnew\trunning\t`+now+`\noldcreated\tcreated\t`+old+`\ncreated\tcreated\t`+now+`\n'`)
+
+	typstCaller := typst.Docker{Executable: "docker-sweep"}
+
+	ids, err := typstCaller.SweepContainers(context.Background(), 10*time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to sweep containers: %v.", err)
+	}
+	if want := []string{"exited", "dead", "old", "oldcreated"}; !slices.Equal(ids, want) {
+		t.Errorf("Unexpected removed containers %q, want %q.", ids, want)
+	}
+
+	calls := readCalls(t, logPath)
+	if len(calls) != 2 {
+		t.Fatalf("Unexpected number of invocations. Got %d, want %d.", len(calls), 2)
+	}
+	if want := "--filter label=" + typst.DockerLabel + " "; !strings.Contains(calls[0], want) {
+		t.Errorf("Invocation %q doesn't contain %q.", calls[0], want)
+	}
+	if want := "rm --force exited dead old oldcreated"; calls[1] != want {
+		t.Errorf("Unexpected invocation %q, want %q.", calls[1], want)
+	}
+}
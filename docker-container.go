@@ -0,0 +1,364 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// DockerContainer manages a long-running Typst Docker container, and invokes Typst commands inside of it via docker exec.
+//
+// The container is started on first use, or by calling Start.
+// If an invocation fails because the container isn't running anymore, the container is restarted and the invocation is repeated.
+// Canceling an invocation only kills its Typst process, see typst.DockerExec.KillProcess.
+// Therefore the image needs to provide tail, sh, pgrep and pkill, like the official Typst image does.
+//
+// This has a lower latency than typst.Docker, as the container doesn't need to be started for every call.
+// Call Close once the container isn't needed anymore, to stop and remove it.
+//
+// Create it with &typst.DockerContainer{}, and don't copy it after first use.
+// The fields must not be changed after first use.
+type DockerContainer struct {
+	Executable     string       // The container runtime executable, like "docker", "podman" or "nerdctl". Defaults to typst.DockerDefaultExecutable if left empty.
+	Image          string       // The image to use, defaults to the latest supported official Typst Docker image if left empty. See: typst.DockerDefaultImage.
+	Name           string       // The name of the container. If left empty, a random name is chosen.
+	User           string       // The user (and optionally group) that the container runs as, like "1000:1000". If left empty, the image's default user is used.
+	TypstPath      string       // The path to the Typst executable inside of the container. Defaults to `typst` if left empty.
+	UserNamespace  string       // The user namespace mode that is passed via "--userns", like "keep-id" for rootless Podman. When left empty, the default of the runtime is used.
+	SELinuxRelabel bool         // Adds the "Z" option to all volumes except anonymous ones, so that they are relabeled for use on SELinux enabled hosts.
+	Environment    *Environment // Optional environment variables of Typst, which are passed into the container via a private "--env-file" of docker exec.
+	Hooks          Hooks        // Optional hooks that are called before and after every invocation of Typst, see typst.SlogHooks.
+
+	// Additional bind-mounts or volumes that are passed via "--volume" flag to Docker.
+	// For details, see: https://docs.docker.com/engine/storage/volumes/#syntax
+	//
+	// Example:
+	//	typst.DockerContainer{Volumes: []string{"/usr/share/fonts:/usr/share/fonts"}} // This makes all system fonts available to Typst running inside the container.
+	Volumes []string
+
+	// Custom "docker run" command line options that are used to start the container go here.
+	// For all available options, see: https://docs.docker.com/reference/cli/docker/container/run/
+	Custom []string
+
+	mutex    sync.Mutex
+	name     string // The name of the started container. Empty if it hasn't been started yet.
+	stopped  string // The name of a previously started container that isn't running anymore, and can be removed.
+	closed   bool
+	versions versionCache // The version of Typst inside of the started container.
+}
+
+// Ensure that DockerContainer implements the ContextCaller and QueryCaller interfaces.
+var (
+	_ ContextCaller = &DockerContainer{}
+	_ QueryCaller   = &DockerContainer{}
+)
+
+// runArgs returns the arguments to start the container with.
+func (c *DockerContainer) runArgs(name string) []string {
+	image := DockerDefaultImage
+	if c.Image != "" {
+		image = c.Image
+	}
+
+	args := []string{"run", "--detach", "--name", name, "--label", DockerLabel + "=container"}
+
+	if c.User != "" {
+		args = append(args, "--user", c.User)
+	}
+	if c.UserNamespace != "" {
+		args = append(args, "--userns", c.UserNamespace)
+	}
+
+	args = append(args, c.Custom...)
+
+	for _, volume := range c.Volumes {
+		args = append(args, "-v", volumeWithLabel(volume, c.SELinuxRelabel))
+	}
+
+	// The entrypoint of the official image is Typst itself, so we replace it with a command that runs forever.
+	args = append(args, "--entrypoint", "tail", image, "-f", "/dev/null")
+
+	return args
+}
+
+// Start starts the container, if it isn't running already.
+//
+// It's not necessary to call this, as the container is started automatically on first use.
+// But it can be used to move the startup cost out of the first invocation, and to check that the container is healthy.
+func (c *DockerContainer) Start(ctx context.Context) error {
+	_, err := c.ensureRunning(ctx)
+	return err
+}
+
+// ensureRunning starts the container, if it hasn't been started yet.
+// It returns the DockerExec caller that invokes Typst inside of the container.
+//
+// The health of an already started container is not checked, see checkFailure.
+func (c *DockerContainer) ensureRunning(ctx context.Context) (DockerExec, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return DockerExec{}, fmt.Errorf("the Docker container has been closed")
+	}
+
+	if c.name != "" {
+		return c.exec(c.name), nil
+	}
+
+	// Reuse the name of a previously started container, so that it gets replaced.
+	name := c.stopped
+	if name == "" {
+		name = c.Name
+	}
+	if name == "" {
+		name = "go-typst-" + randomID()
+	}
+
+	// Remove the stopped container, otherwise we can't start a new one with the same name.
+	// This is not forced, so that a container that is running again for some reason is never removed.
+	if c.stopped != "" {
+		docker(ctx, c.Executable, "rm", c.stopped) //nolint:errcheck
+		c.stopped = ""
+	}
+
+	if _, err := docker(ctx, c.Executable, c.runArgs(name)...); err != nil {
+		return DockerExec{}, fmt.Errorf("failed to start Docker container: %w", err)
+	}
+
+	// Check that Typst can be invoked inside of the container.
+	// Nobody else knows about the container yet, so it can be removed safely.
+	exec := c.exec(name)
+	s, err := exec.VersionStringWithContext(ctx)
+	if err != nil {
+		docker(context.Background(), c.Executable, "rm", "--force", name) //nolint:errcheck
+		return DockerExec{}, fmt.Errorf("the Docker container is not healthy: %w", err)
+	}
+	c.name = name
+
+	// The image may have changed since the previous container was started.
+	c.versions.reset()
+	if version, err := ParseVersion(s); err == nil {
+		c.versions.store(version)
+	}
+
+	return exec, nil
+}
+
+// checkFailure checks the container with the given name after an invocation in it has failed with err.
+// If the container isn't running anymore, it is replaced by a new one on the next invocation.
+//
+// It returns true if Typst wasn't invoked at all because the container wasn't running, in which case the invocation can be repeated.
+func (c *DockerContainer) checkFailure(ctx context.Context, name string, err error) bool {
+	// Only errors of the container runtime can be caused by a stopped container.
+	var dockerErr *DockerError
+	if !errors.As(err, &dockerErr) || ctx.Err() != nil {
+		return false
+	}
+
+	notRunning := dockerErr.Kind == DockerErrorContainerNotFound || dockerErr.Kind == DockerErrorContainerNotRunning
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.name != name {
+		// Another invocation has already handled the failure.
+		return notRunning && !c.closed
+	}
+
+	switch dockerErr.Kind {
+	case DockerErrorContainerNotFound:
+		c.name = ""
+		return true
+	case DockerErrorContainerNotRunning:
+		c.name, c.stopped = "", name
+		return true
+	}
+
+	// The error doesn't tell, so ask the container runtime.
+	// If that fails too, the container is kept, as it may still be in use by other invocations.
+	output, inspectErr := docker(ctx, c.Executable, "inspect", "--format", "{{.State.Running}}", name)
+	if inspectErr == nil && strings.TrimSpace(output) == "false" {
+		c.name, c.stopped = "", name
+	}
+
+	return false
+}
+
+// exec returns a DockerExec caller for the container with the given name.
+func (c *DockerContainer) exec(name string) DockerExec {
+	return DockerExec{Executable: c.Executable, ContainerName: name, TypstPath: c.TypstPath, Environment: c.Environment, KillProcess: true}
+}
+
+// ContainerName returns the name of the started container.
+// It's empty if the container hasn't been started yet.
+func (c *DockerContainer) ContainerName() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.name
+}
+
+// Close stops and removes the container.
+// The caller can't be used afterwards.
+func (c *DockerContainer) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.closed = true
+
+	if c.stopped != "" {
+		docker(context.Background(), c.Executable, "rm", c.stopped) //nolint:errcheck
+		c.stopped = ""
+	}
+
+	if c.name == "" {
+		return nil
+	}
+
+	if _, err := docker(context.Background(), c.Executable, "rm", "--force", c.name); err != nil {
+		return fmt.Errorf("failed to remove Docker container: %w", err)
+	}
+	c.name = ""
+
+	return nil
+}
+
+// run implements the runner interface.
+//
+// If the container wasn't running, the invocation is repeated in a new container.
+func (c *DockerContainer) run(ctx context.Context, inv *invocation) error {
+	exec, err := c.ensureRunning(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The first attempt may have consumed the input, so it's buffered to be able to repeat the invocation.
+	var input []byte
+	if inv.stdin != nil {
+		if input, err = io.ReadAll(inv.stdin); err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+	}
+
+	// Only the stderr output of the last attempt is passed on, the one of a failed attempt contains the error of the container runtime.
+	var stderr bytes.Buffer
+	attempt := *inv
+	attempt.stderr = &stderr
+	if inv.stdin != nil {
+		attempt.stdin = bytes.NewReader(input)
+	}
+
+	err = exec.run(ctx, &attempt)
+	if err != nil && c.checkFailure(ctx, exec.ContainerName, err) {
+		if exec, err = c.ensureRunning(ctx); err != nil {
+			return err
+		}
+
+		stderr.Reset()
+		if inv.stdin != nil {
+			attempt.stdin = bytes.NewReader(input)
+		}
+		err = exec.run(ctx, &attempt)
+	}
+
+	if inv.stderr != nil {
+		stderr.WriteTo(inv.stderr) //nolint:errcheck
+	}
+
+	return err
+}
+
+// newWorkspace implements the runner interface.
+func (c *DockerContainer) newWorkspace(ctx context.Context, parent string) (*workspace, error) {
+	exec, err := c.ensureRunning(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := exec.newWorkspace(ctx, parent)
+	if err != nil && c.checkFailure(ctx, exec.ContainerName, err) {
+		if exec, err = c.ensureRunning(ctx); err != nil {
+			return nil, err
+		}
+		return exec.newWorkspace(ctx, parent)
+	}
+
+	return ws, err
+}
+
+// kind implements the runner interface.
+func (c *DockerContainer) kind() CallerKind {
+	return CallerKindDockerContainer
+}
+
+// hooks implements the runner interface.
+func (c *DockerContainer) hooks() Hooks {
+	return c.Hooks
+}
+
+// versionCache implements the versionCacher interface.
+func (c *DockerContainer) versionCache() *versionCache {
+	return &c.versions
+}
+
+// VersionString returns the Typst version as a string.
+func (c *DockerContainer) VersionString() (string, error) {
+	return c.VersionStringWithContext(context.Background())
+}
+
+// VersionStringWithContext returns the Typst version as a string.
+// The Typst process of the invocation is killed once ctx is done.
+func (c *DockerContainer) VersionStringWithContext(ctx context.Context) (string, error) {
+	return versionString(ctx, c)
+}
+
+// Fonts returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+func (c *DockerContainer) Fonts(options *OptionsFonts) ([]string, error) {
+	return c.FontsWithContext(context.Background(), options)
+}
+
+// FontsWithContext returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+// The Typst process of the invocation is killed once ctx is done.
+func (c *DockerContainer) FontsWithContext(ctx context.Context, options *OptionsFonts) ([]string, error) {
+	return fonts(ctx, c, options)
+}
+
+// Compile takes a Typst document from input, and renders it into the output writer.
+// The options parameter is optional, and can be nil.
+func (c *DockerContainer) Compile(input io.Reader, output io.Writer, options *OptionsCompile) error {
+	return c.CompileWithContext(context.Background(), input, output, options)
+}
+
+// CompileWithContext takes a Typst document from input, and renders it into the output writer.
+// The options parameter is optional, and can be nil.
+// The Typst process of the invocation is killed once ctx is done.
+func (c *DockerContainer) CompileWithContext(ctx context.Context, input io.Reader, output io.Writer, options *OptionsCompile) error {
+	return compile(ctx, c, input, output, options)
+}
+
+// Query takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+func (c *DockerContainer) Query(input io.Reader, result any, options *OptionsQuery) error {
+	return c.QueryWithContext(context.Background(), input, result, options)
+}
+
+// QueryWithContext takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+// The Typst process of the invocation is killed once ctx is done.
+func (c *DockerContainer) QueryWithContext(ctx context.Context, input io.Reader, result any, options *OptionsQuery) error {
+	return query(ctx, c, input, result, options)
+}
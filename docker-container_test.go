@@ -0,0 +1,66 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst_test
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+
+	"github.com/Dadido3/go-typst"
+)
+
+func TestDockerContainer(t *testing.T) {
+	typstCaller := &typst.DockerContainer{
+		Volumes: []string{"./test-files:/test-files"},
+	}
+	t.Cleanup(func() {
+		if err := typstCaller.Close(); err != nil {
+			t.Errorf("Failed to close container: %v.", err)
+		}
+	})
+
+	v, err := typstCaller.VersionString()
+	if err != nil {
+		t.Fatalf("Failed to get typst version: %v.", err)
+	}
+	t.Logf("VersionString: %s", v)
+
+	result, err := typstCaller.Fonts(&typst.OptionsFonts{IgnoreSystemFonts: true, FontPaths: []string{"/test-files"}})
+	if err != nil {
+		t.Fatalf("Failed to get available fonts: %v.", err)
+	}
+	if len(result) != 5 {
+		t.Errorf("Unexpected number of detected fonts. Got %d, want %d.", len(result), 5)
+	}
+
+	// Kill the container, it should be restarted automatically.
+	if err := exec.Command("docker", "kill", typstCaller.ContainerName()).Run(); err != nil {
+		t.Fatalf("Failed to kill container: %v.", err)
+	}
+
+	r := bytes.NewBufferString(`#import "hello-world-template.typ": template
+#show: doc => template()`)
+
+	var w bytes.Buffer
+	if err := typstCaller.Compile(r, &w, &typst.OptionsCompile{Root: "/test-files"}); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+	if w.Len() == 0 {
+		t.Errorf("No output was written.")
+	}
+}
+
+func TestDockerContainer_Closed(t *testing.T) {
+	typstCaller := &typst.DockerContainer{}
+	if err := typstCaller.Close(); err != nil {
+		t.Fatalf("Failed to close container: %v.", err)
+	}
+
+	if _, err := typstCaller.VersionString(); err == nil {
+		t.Errorf("Expected error, but got nil.")
+	}
+}
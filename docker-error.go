@@ -0,0 +1,127 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// DockerErrorKind classifies a typst.DockerError.
+type DockerErrorKind string
+
+const (
+	DockerErrorUnknown             DockerErrorKind = "unknown"               // Docker has failed for an unknown reason.
+	DockerErrorExecutableNotFound  DockerErrorKind = "executable-not-found"  // The Docker executable couldn't be found.
+	DockerErrorDaemonUnreachable   DockerErrorKind = "daemon-unreachable"    // The Docker daemon isn't running, or can't be reached.
+	DockerErrorPermissionDenied    DockerErrorKind = "permission-denied"     // The user has no permission to access the Docker daemon.
+	DockerErrorImageNotFound       DockerErrorKind = "image-not-found"       // The image doesn't exist, or access to it was denied.
+	DockerErrorPullFailed          DockerErrorKind = "pull-failed"           // The image couldn't be pulled, e.g. because of network problems or rate limits.
+	DockerErrorContainerNotFound   DockerErrorKind = "container-not-found"   // The container doesn't exist.
+	DockerErrorContainerNotRunning DockerErrorKind = "container-not-running" // The container exists, but isn't running.
+)
+
+// DockerError is returned by the Docker based callers when Docker or any other container runtime itself has failed, rather than Typst.
+// This is usually an infrastructure problem, and not a problem with the document.
+type DockerError struct {
+	Kind     DockerErrorKind
+	ExitCode int    // The exit code of the Docker CLI, or -1 if it couldn't be started.
+	Stderr   string // The raw output from stderr.
+	Inner    error
+}
+
+func (e *DockerError) Error() string {
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		return fmt.Sprintf("docker error (%s): %s", e.Kind, stderr)
+	}
+	return fmt.Sprintf("docker error (%s): %v", e.Kind, e.Inner)
+}
+
+func (e *DockerError) Unwrap() error {
+	return e.Inner
+}
+
+// dockerLineRegex matches lines of stderr output that are written by the container runtime, like Docker, Podman or nerdctl.
+// Typst diagnostics never match, as they start with a lowercase severity followed by a colon, a path or white space.
+// Informational output like "Unable to find image ... locally" is not matched, as it's also written when the image is pulled successfully.
+var dockerLineRegex = regexp.MustCompile(`(?m)^(?:docker: )?(?:Error response from daemon: |Error: |error during connect: |Cannot connect |Got permission denied |permission denied |FATA\[\d+\] |time="[^"]*" level=fatal ).*$`)
+
+// dockerErrorPatterns maps patterns of the error messages of Docker, Podman and nerdctl to their kind.
+// The patterns are checked in order.
+var dockerErrorPatterns = []struct {
+	kind  DockerErrorKind
+	regex *regexp.Regexp
+}{
+	{DockerErrorPermissionDenied, regexp.MustCompile(`(?i)permission denied while trying to connect to the docker daemon`)},
+	{DockerErrorDaemonUnreachable, regexp.MustCompile(`(?i)cannot connect to the docker daemon|is the docker daemon running|error during connect|cannot connect to podman|rootless containerd not running|cannot access containerd socket`)},
+	{DockerErrorImageNotFound, regexp.MustCompile(`(?i)pull access denied|manifest unknown|manifest for \S+ not found|repository does not exist|no such image|image not known|did not resolve to an alias`)},
+	{DockerErrorPullFailed, regexp.MustCompile(`(?i)failed to resolve reference|error pulling image|toomanyrequests|failed to pull|failed to do request`)},
+	{DockerErrorContainerNotFound, regexp.MustCompile(`(?i)no such container|no container with name or id`)},
+	{DockerErrorContainerNotRunning, regexp.MustCompile(`(?i)is not running|is paused|is restarting|container state improper`)},
+}
+
+// classifyDockerStderr returns the kind of the Docker error that is described in stderr.
+// It returns an empty kind if stderr doesn't contain any output of Docker.
+func classifyDockerStderr(stderr string) DockerErrorKind {
+	lines := dockerLineRegex.FindAllString(stderr, -1)
+	if len(lines) == 0 {
+		return ""
+	}
+
+	dockerOutput := strings.Join(lines, "\n")
+	for _, pattern := range dockerErrorPatterns {
+		if pattern.regex.MatchString(dockerOutput) {
+			return pattern.kind
+		}
+	}
+
+	return DockerErrorUnknown
+}
+
+// newDockerError returns a *DockerError for the given error of a Docker CLI invocation that is known to have failed because of Docker.
+func newDockerError(err error, stderr string) *DockerError {
+	dockerErr := &DockerError{Kind: DockerErrorUnknown, ExitCode: -1, Stderr: stderr, Inner: err}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		dockerErr.ExitCode = exitErr.ExitCode()
+	}
+
+	if errors.Is(err, exec.ErrNotFound) {
+		dockerErr.Kind = DockerErrorExecutableNotFound
+	} else if kind := classifyDockerStderr(stderr); kind != "" {
+		dockerErr.Kind = kind
+	}
+
+	return dockerErr
+}
+
+// dockerInvocationError checks whether the error of a Docker CLI invocation that runs Typst was caused by Docker or by Typst.
+// It returns a *DockerError in the first case, and err unchanged otherwise.
+func dockerInvocationError(err error, stderr string) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		// Check if the Docker CLI couldn't be started at all.
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			return newDockerError(err, stderr)
+		}
+		return err
+	}
+
+	// Docker and Podman use the exit codes 125 and above for their own errors.
+	// But some errors, like missing containers with Docker or any error with nerdctl, result in an exit code of 1.
+	// Therefore the output of the runtime has precedence.
+	if classifyDockerStderr(stderr) != "" || exitErr.ExitCode() >= 125 {
+		return newDockerError(err, stderr)
+	}
+
+	// Typst related error.
+	return err
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import "testing"
+
+func Test_classifyDockerStderr(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   DockerErrorKind
+	}{
+		{"typst error", "<stdin>:1:2: error: unexpected argument\n", ""},
+		{"typst error with human format", "error: No such container in this document\n  ┌─ <stdin>:1:2\n  │\n1 │ Error response from daemon: is not running\n  │  ^\n\n", ""},
+		{"typst error after pull", "Unable to find image 'ghcr.io/typst/typst:0.14.0' locally\n0.14.0: Pulling from typst/typst\nStatus: Downloaded newer image for ghcr.io/typst/typst:0.14.0\nerror: file not found\n", ""},
+		{"daemon unreachable", "Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?\n", DockerErrorDaemonUnreachable},
+		{"daemon unreachable windows", "error during connect: this error may indicate that the docker daemon is not running\n", DockerErrorDaemonUnreachable},
+		{"permission denied", "docker: permission denied while trying to connect to the Docker daemon socket at unix:///var/run/docker.sock: Head \"http://%2Fvar%2Frun%2Fdocker.sock/_ping\": dial unix /var/run/docker.sock: connect: permission denied\n", DockerErrorPermissionDenied},
+		{"image not found", "Unable to find image 'foo/bar:latest' locally\ndocker: Error response from daemon: pull access denied for foo/bar, repository does not exist or may require 'docker login': denied: requested access to the resource is denied\n", DockerErrorImageNotFound},
+		{"tag not found", "Unable to find image 'ghcr.io/typst/typst:9.9.9' locally\ndocker: Error response from daemon: manifest unknown\n", DockerErrorImageNotFound},
+		{"pull failed", "docker: Error response from daemon: failed to resolve reference \"ghcr.io/typst/typst:0.14.0\": failed to do request: Head \"https://ghcr.io/v2/typst/typst/manifests/0.14.0\": dial tcp: lookup ghcr.io: no such host\n", DockerErrorPullFailed},
+		{"container not found", "Error response from daemon: No such container: typst-instance\n", DockerErrorContainerNotFound},
+		{"container not running", "Error response from daemon: container 4f66ad9a0b2e is not running\n", DockerErrorContainerNotRunning},
+		{"container paused", "Error response from daemon: Container typst-instance is paused, unpause the container before exec\n", DockerErrorContainerNotRunning},
+		{"unknown", "docker: Error response from daemon: something else went wrong\n", DockerErrorUnknown},
+		{"podman container not found", "Error: no container with name or ID \"typst-instance\" found: no such container\n", DockerErrorContainerNotFound},
+		{"podman container not running", "Error: can only create exec sessions on running containers: container state improper\n", DockerErrorContainerNotRunning},
+		{"podman image not found", "Trying to pull ghcr.io/typst/typst:9.9.9...\nError: initializing source docker://ghcr.io/typst/typst:9.9.9: reading manifest 9.9.9 in ghcr.io/typst/typst: manifest unknown\n", DockerErrorImageNotFound},
+		{"nerdctl container not found", "time=\"2025-10-17T12:00:00Z\" level=fatal msg=\"no such container: typst-instance\"\n", DockerErrorContainerNotFound},
+		{"nerdctl daemon unreachable", "FATA[0000] rootless containerd not running? (hint: use `containerd-rootless-setuptool.sh install` to start rootless containerd)\n", DockerErrorDaemonUnreachable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyDockerStderr(tt.stderr); got != tt.want {
+				t.Errorf("classifyDockerStderr() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,8 +6,8 @@
 package typst
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os/exec"
@@ -21,9 +21,21 @@ import (
 //
 // This uses docker exec, and therefore needs you to set up a running container beforehand.
 // For a less complex setup see typst.Docker.
+//
+// When the context of an invocation is done, the whole container is killed.
+// This is necessary as stopping the Docker CLI doesn't stop the Typst process inside of the container.
+// Therefore you should use a dedicated container when you make use of cancellation, or enable KillProcess.
 type DockerExec struct {
-	ContainerName string // The name of the running container you want to invoke Typst in.
-	TypstPath     string // The path to the Typst executable inside of the container. Defaults to `typst` if left empty.
+	Executable    string       // The container runtime executable, like "docker", "podman" or "nerdctl". Defaults to typst.DockerDefaultExecutable if left empty.
+	ContainerName string       // The name of the running container you want to invoke Typst in.
+	TypstPath     string       // The path to the Typst executable inside of the container. Defaults to `typst` if left empty.
+	Environment   *Environment // Optional environment variables of Typst, which are passed into the container via a private "--env-file".
+	Hooks         Hooks        // Optional hooks that are called before and after every invocation of Typst, see typst.SlogHooks.
+
+	// KillProcess makes canceled invocations kill only their own Typst process, instead of the whole container.
+	// Typst is then invoked through a shell, so that its process can be identified.
+	// This needs sh, pgrep and pkill inside of the container, like the official Typst image provides.
+	KillProcess bool
 
 	// Custom "docker exec" command line options go here.
 	// For all available options, see: https://docs.docker.com/reference/cli/docker/container/exec/
@@ -33,11 +45,16 @@ type DockerExec struct {
 	Custom []string
 }
 
-// Ensure that DockerExec implements the Caller interface.
-var _ Caller = DockerExec{}
+// Ensure that DockerExec implements the ContextCaller and QueryCaller interfaces.
+var (
+	_ ContextCaller = DockerExec{}
+	_ QueryCaller   = DockerExec{}
+)
 
 // args returns docker related arguments.
-func (d DockerExec) args() ([]string, error) {
+// If KillProcess is set, the marker is passed to the shell that Typst is invoked with, see killArgs.
+// The envArgs are the arguments that pass the environment variables, see Environment.envFile.
+func (d DockerExec) args(marker string, envArgs []string) ([]string, error) {
 	if d.ContainerName == "" {
 		return nil, fmt.Errorf("the provided ContainerName field is empty")
 	}
@@ -50,111 +67,174 @@ func (d DockerExec) args() ([]string, error) {
 	// Argument -i is needed for stdio to work.
 	args := []string{"exec", "-i"}
 
+	args = append(args, envArgs...)
 	args = append(args, d.Custom...)
 
-	args = append(args, d.ContainerName, typstPath)
+	args = append(args, d.ContainerName)
+	if d.KillProcess {
+		// The shell stays the parent of Typst, and carries the marker in its command line.
+		args = append(args, "sh", "-c", `"$@"; exit $?`, marker)
+	}
+	args = append(args, typstPath)
 
 	return args, nil
 }
 
-// VersionString returns the Typst version as a string.
-func (d DockerExec) VersionString() (string, error) {
-	args, err := d.args()
+// killArgs returns docker related arguments to kill the Typst process that was started with the given marker.
+// Without KillProcess, the whole container is killed.
+func (d DockerExec) killArgs(marker string) []string {
+	if !d.KillProcess {
+		return []string{"kill", d.ContainerName}
+	}
+
+	// The brackets prevent the pattern from matching the command line of the shell that runs pgrep.
+	pattern := "[" + marker[:1] + "]" + marker[1:]
+
+	return []string{"exec", d.ContainerName, "sh", "-c", `for pid in $(pgrep -f "$0"); do pkill -P "$pid"; done`, pattern}
+}
+
+// run implements the runner interface.
+func (d DockerExec) run(ctx context.Context, inv *invocation) error {
+	marker := "go-typst-invocation-" + randomID()
+
+	envArgs, removeEnvFile, err := d.Environment.envFile()
 	if err != nil {
-		return "", err
+		return err
 	}
-	args = append(args, "--version")
+	defer removeEnvFile()
 
-	cmd := exec.Command("docker", args...)
+	args, err := d.args(marker, envArgs)
+	if err != nil {
+		return err
+	}
+	args = append(args, inv.args...)
 
-	var output, errBuffer bytes.Buffer
-	cmd.Stdout = &output
-	cmd.Stderr = &errBuffer
+	executable := dockerExecutable(d.Executable)
+
+	// Stopping the Docker CLI doesn't stop the process inside of the container, so we have to kill it or the container explicitly.
+	kill := func() {
+		exec.Command(executable, d.killArgs(marker)...).Run() //nolint:errcheck
+	}
+
+	cmd := commandContext(ctx, kill, executable, args...)
+	cmd.Stdin = inv.stdin
+	cmd.Stdout = inv.stdout
+
+	var errBuffer bytes.Buffer
+	cmd.Stderr = io.MultiWriter(inv.stderr, &errBuffer)
 
 	if err := cmd.Run(); err != nil {
-		switch err := err.(type) {
-		case *exec.ExitError:
-			return "", ParseStderr(errBuffer.String(), err)
-		default:
-			return "", err
-		}
+		return dockerInvocationError(err, errBuffer.String())
 	}
 
-	return output.String(), nil
+	return nil
 }
 
-// Fonts returns all fonts that are available to Typst.
-// The options parameter is optional, and can be nil.
-func (d DockerExec) Fonts(options *OptionsFonts) ([]string, error) {
-	args, err := d.args()
+// newWorkspace implements the runner interface.
+//
+// The workspace is created inside of the container, and its content is copied to the host via docker cp.
+func (d DockerExec) newWorkspace(ctx context.Context, parent string) (*workspace, error) {
+	if d.ContainerName == "" {
+		return nil, fmt.Errorf("the provided ContainerName field is empty")
+	}
+
+	ws, err := newHostWorkspace(parent)
 	if err != nil {
 		return nil, err
 	}
 
-	if options == nil {
-		options = new(OptionsFonts)
+	ws.typstDir = "/tmp/go-typst-" + randomID()
+	ws.container = true
+
+	if _, err := docker(ctx, d.Executable, "exec", d.ContainerName, "mkdir", "-p", ws.typstDir); err != nil {
+		ws.close() //nolint:errcheck
+		return nil, err
+	}
+
+	ws.push = func(ctx context.Context) error {
+		_, err := docker(ctx, d.Executable, "cp", ws.hostDir+"/.", d.ContainerName+":"+ws.typstDir)
+		return err
+	}
+	ws.pull = func(ctx context.Context) error {
+		_, err := docker(ctx, d.Executable, "cp", d.ContainerName+":"+ws.typstDir+"/.", ws.hostDir)
+		return err
+	}
+	ws.remove = func() error {
+		_, err := docker(context.Background(), d.Executable, "exec", d.ContainerName, "rm", "-rf", ws.typstDir)
+		return err
 	}
-	args = append(args, options.Args()...)
 
-	cmd := exec.Command("docker", args...)
+	return ws, nil
+}
 
-	var output, errBuffer bytes.Buffer
-	cmd.Stdout = &output
-	cmd.Stderr = &errBuffer
+// kind implements the runner interface.
+func (d DockerExec) kind() CallerKind {
+	return CallerKindDockerExec
+}
 
-	if err := cmd.Run(); err != nil {
-		switch err := err.(type) {
-		case *exec.ExitError:
-			return nil, ParseStderr(errBuffer.String(), err)
-		default:
-			return nil, err
-		}
-	}
+// hooks implements the runner interface.
+func (d DockerExec) hooks() Hooks {
+	return d.Hooks
+}
 
-	var result []string
-	scanner := bufio.NewScanner(&output)
-	for scanner.Scan() {
-		result = append(result, scanner.Text())
-	}
+// VersionString returns the Typst version as a string.
+func (d DockerExec) VersionString() (string, error) {
+	return d.VersionStringWithContext(context.Background())
+}
 
-	return result, nil
+// VersionStringWithContext returns the Typst version as a string.
+// The Typst process of the invocation is killed once ctx is done.
+func (d DockerExec) VersionStringWithContext(ctx context.Context) (string, error) {
+	return versionString(ctx, d)
+}
+
+// Fonts returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+func (d DockerExec) Fonts(options *OptionsFonts) ([]string, error) {
+	return d.FontsWithContext(context.Background(), options)
+}
+
+// FontsWithContext returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+// The Typst process of the invocation is killed once ctx is done.
+func (d DockerExec) FontsWithContext(ctx context.Context, options *OptionsFonts) ([]string, error) {
+	return fonts(ctx, d, options)
 }
 
 // Compile takes a Typst document from input, and renders it into the output writer.
 // The options parameter is optional, and can be nil.
 func (d DockerExec) Compile(input io.Reader, output io.Writer, options *OptionsCompile) error {
-	args, err := d.args()
-	if err != nil {
-		return err
-	}
-
-	if options == nil {
-		options = new(OptionsCompile)
-	}
-	args = append(args, options.Args()...)
+	return d.CompileWithContext(context.Background(), input, output, options)
+}
 
-	cmd := exec.Command("docker", args...)
-	cmd.Stdin = input
-	cmd.Stdout = output
+// CompileWithContext takes a Typst document from input, and renders it into the output writer.
+// The options parameter is optional, and can be nil.
+// The Typst process of the invocation is killed once ctx is done.
+func (d DockerExec) CompileWithContext(ctx context.Context, input io.Reader, output io.Writer, options *OptionsCompile) error {
+	return compile(ctx, d, input, output, options)
+}
 
-	errBuffer := bytes.Buffer{}
-	cmd.Stderr = &errBuffer
+// Query takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+func (d DockerExec) Query(input io.Reader, result any, options *OptionsQuery) error {
+	return d.QueryWithContext(context.Background(), input, result, options)
+}
 
-	if err := cmd.Run(); err != nil {
-		switch err := err.(type) {
-		case *exec.ExitError:
-			if err.ExitCode() >= 125 {
-				// Most likely docker related error.
-				// TODO: Find a better way to distinguish between Typst or Docker errors.
-				return fmt.Errorf("exit code %d: %s", err.ExitCode(), errBuffer.String())
-			} else {
-				// Typst related error.
-				return ParseStderr(errBuffer.String(), err)
-			}
-		default:
-			return err
-		}
-	}
+// QueryWithContext takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+// The Typst process of the invocation is killed once ctx is done.
+func (d DockerExec) QueryWithContext(ctx context.Context, input io.Reader, result any, options *OptionsQuery) error {
+	return query(ctx, d, input, result, options)
+}
 
-	return nil
+// Watch starts Typst in watch mode, which recompiles the document at the input path whenever it or any of its dependencies change.
+// The result is written to the output path, which may be left empty to let Typst derive it from the input path.
+// The paths are resolved inside of the container.
+// The options parameter is optional, and can be nil.
+//
+// The returned Watcher reports all compilations until ctx is done, or until it is closed.
+func (d DockerExec) Watch(ctx context.Context, input, output string, options *OptionsCompile) (*Watcher, error) {
+	return watch(ctx, d, input, output, options)
 }
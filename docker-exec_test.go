@@ -7,6 +7,7 @@ package typst_test
 
 import (
 	"bytes"
+	"errors"
 	"image"
 	"os/exec"
 	"strconv"
@@ -38,6 +39,9 @@ func TestDockerExec(t *testing.T) {
 		{"FontsWithFontPaths", dockerExec_FontsWithFontPaths},
 		{"Compile", dockerExec_Compile},
 		{"CompileWithWorkingDir", dockerExec_CompileWithWorkingDir},
+		{"Query", dockerExec_Query},
+		{"CompileProject", dockerExec_CompileProject},
+		{"CompileAttachments", dockerExec_CompileAttachments},
 	}
 
 	for _, test := range tests {
@@ -161,6 +165,22 @@ func dockerExec_CompileWithWorkingDir(t *testing.T) {
 	}
 }
 
+func dockerExec_Query(t *testing.T) {
+	typstCaller := typst.DockerExec{
+		ContainerName: "typst-instance",
+	}
+
+	r := bytes.NewBufferString(`#metadata("Hello") <greeting>`)
+
+	var greeting string
+	if err := typstCaller.Query(r, &greeting, &typst.OptionsQuery{Selector: "<greeting>", Field: "value", One: true}); err != nil {
+		t.Fatalf("Failed to query document: %v.", err)
+	}
+	if greeting != "Hello" {
+		t.Errorf("Unexpected query result. Got %q, want %q.", greeting, "Hello")
+	}
+}
+
 func TestDockerExec_EmptyContainerName(t *testing.T) {
 	typstCaller := typst.DockerExec{
 		ContainerName: "",
@@ -179,6 +199,22 @@ func TestDockerExec_NonRunningContainer(t *testing.T) {
 
 	_, err := typstCaller.VersionString()
 	if err == nil {
-		t.Errorf("Expected error, but got nil.")
+		t.Fatalf("Expected error, but got nil.")
+	}
+
+	var errDocker *typst.DockerError
+	if !errors.As(err, &errDocker) {
+		t.Fatalf("Expected error of type %T, got %T: %v.", errDocker, err, err)
+	}
+	if errDocker.Kind != typst.DockerErrorContainerNotFound {
+		t.Errorf("Unexpected error kind. Got %q, want %q.", errDocker.Kind, typst.DockerErrorContainerNotFound)
 	}
 }
+
+func dockerExec_CompileProject(t *testing.T) {
+	testCompileProject(t, typst.DockerExec{ContainerName: "typst-instance"})
+}
+
+func dockerExec_CompileAttachments(t *testing.T) {
+	testCompileAttachments(t, typst.DockerExec{ContainerName: "typst-instance"})
+}
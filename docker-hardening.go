@@ -0,0 +1,131 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// dockerMemoryRegex matches memory limits like "512m", "1.5g" or "2GiB".
+// This is the same grammar that Docker uses to parse sizes in bytes.
+var dockerMemoryRegex = regexp.MustCompile(`^\d+(\.\d+)* ?[kKmMgGtTpP]?[iI]?[bB]?$`)
+
+// dockerCapabilityRegex matches Linux capabilities like "ALL", "NET_RAW" or "CAP_NET_RAW".
+var dockerCapabilityRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z_]*$`)
+
+// Hardened returns a copy of d with a restrictive configuration that is suitable for compiling untrusted documents.
+//
+// The container has no network access, its root filesystem is read-only with a tmpfs at "/tmp", and it runs as the current user without any capabilities.
+// Memory is limited to 2 GiB, and the number of processes and threads to 512.
+// Network, Tmpfs, Memory, PidsLimit and CapDrop are only set if they are empty, so values that are set beforehand are kept.
+//
+// Without network access Typst can't download packages, so they have to be provided via OptionsCompile.PackagePath or PackageCachePath.
+// It can be combined with other presets, like typst.Podman().Hardened(), and the returned caller can be customized further.
+func (d Docker) Hardened() Docker {
+	if d.Network == "" {
+		d.Network = "none"
+	}
+	d.ReadOnly = true
+	if len(d.Tmpfs) == 0 {
+		d.Tmpfs = []string{"/tmp"}
+	}
+	if d.Memory == "" {
+		d.Memory = "2g"
+	}
+	if d.PidsLimit == 0 {
+		d.PidsLimit = 512
+	}
+	if len(d.CapDrop) == 0 {
+		d.CapDrop = []string{"ALL"}
+	}
+	d.NoNewPrivileges = true
+
+	// There is no uid on Windows, and Docker Desktop takes care of file ownership there.
+	d.CurrentUser = os.Getuid() >= 0
+
+	return d
+}
+
+// Validate checks the hardening and resource limit fields for malformed values.
+//
+// It returns nil if the fields are valid, otherwise all problems are joined into one error.
+// Every problem is described by a *typst.ValidationError.
+// This is called automatically before every invocation.
+func (d Docker) Validate() error {
+	var v validator
+
+	if strings.ContainsAny(d.Network, " \t\r\n") {
+		v.addf("Network", "%q must not contain white space", d.Network)
+	}
+
+	for _, tmpfs := range d.Tmpfs {
+		if target, _, _ := strings.Cut(tmpfs, ":"); !strings.HasPrefix(target, "/") {
+			v.addf("Tmpfs", "%q is not an absolute path inside of the container", tmpfs)
+		}
+	}
+
+	if d.Memory != "" && !dockerMemoryRegex.MatchString(d.Memory) {
+		v.addf("Memory", "%q is not a valid memory limit, like %q or %q", d.Memory, "512m", "2g")
+	}
+
+	if d.CPUs < 0 {
+		v.addf("CPUs", "must not be negative, got %v", d.CPUs)
+	}
+
+	if d.PidsLimit < -1 {
+		v.addf("PidsLimit", "must not be less than -1, got %d", d.PidsLimit)
+	}
+
+	for _, capability := range d.CapDrop {
+		if !dockerCapabilityRegex.MatchString(capability) {
+			v.addf("CapDrop", "%q is not a valid capability", capability)
+		}
+	}
+
+	if d.CurrentUser && os.Getuid() < 0 {
+		v.addf("CurrentUser", "not supported on this platform")
+	}
+
+	return v.err()
+}
+
+// hardeningArgs returns the "docker run" arguments of the hardening and resource limit fields.
+func (d Docker) hardeningArgs() []string {
+	var args []string
+
+	if d.Network != "" {
+		args = append(args, "--network", d.Network)
+	}
+	if d.ReadOnly {
+		args = append(args, "--read-only")
+	}
+	for _, tmpfs := range d.Tmpfs {
+		args = append(args, "--tmpfs", tmpfs)
+	}
+	if d.Memory != "" {
+		args = append(args, "--memory", d.Memory)
+	}
+	if d.CPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(d.CPUs, 'f', -1, 64))
+	}
+	if d.PidsLimit != 0 {
+		args = append(args, "--pids-limit", strconv.Itoa(d.PidsLimit))
+	}
+	for _, capability := range d.CapDrop {
+		args = append(args, "--cap-drop", capability)
+	}
+	if d.NoNewPrivileges {
+		args = append(args, "--security-opt", "no-new-privileges")
+	}
+	if d.CurrentUser {
+		args = append(args, "--user", strconv.Itoa(os.Getuid())+":"+strconv.Itoa(os.Getgid()))
+	}
+
+	return args
+}
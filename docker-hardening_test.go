@@ -0,0 +1,115 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+//go:build unix
+
+package typst_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Dadido3/go-typst"
+)
+
+func TestDocker_Hardened(t *testing.T) {
+	logPath := fakeRuntime(t, "docker-hardened", `echo "typst 0.14.0 (b790c6d5)"`)
+
+	typstCaller := typst.Docker{Executable: "docker-hardened", Memory: "512m", CPUs: 1.5}.Hardened()
+
+	if _, err := typstCaller.VersionString(); err != nil {
+		t.Fatalf("Failed to get typst version: %v.", err)
+	}
+
+	calls := readCalls(t, logPath)
+	if len(calls) != 1 {
+		t.Fatalf("Unexpected number of invocations. Got %d, want %d.", len(calls), 1)
+	}
+	for _, want := range []string{
+		" --network none ",
+		" --read-only --tmpfs /tmp ",
+		" --memory 512m ",
+		" --cpus 1.5 ",
+		" --pids-limit 512 ",
+		" --cap-drop ALL ",
+		" --security-opt no-new-privileges ",
+		fmt.Sprintf(" --user %d:%d ", os.Getuid(), os.Getgid()),
+	} {
+		if !strings.Contains(calls[0], want) {
+			t.Errorf("Invocation %q doesn't contain %q.", calls[0], want)
+		}
+	}
+}
+
+func TestDocker_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller typst.Docker
+		fields []string
+	}{
+		{"Valid", typst.Docker{}.Hardened(), nil},
+		{"Network", typst.Docker{Network: "none --privileged"}, []string{"Network"}},
+		{"Tmpfs", typst.Docker{Tmpfs: []string{"tmp:size=64m"}}, []string{"Tmpfs"}},
+		{"Memory", typst.Docker{Memory: "2 gigabytes"}, []string{"Memory"}},
+		{"MemoryUnits", typst.Docker{Memory: "1.5g"}, nil},
+		{"MemoryBytes", typst.Docker{Memory: "512MB"}, nil},
+		{"MemoryBinary", typst.Docker{Memory: "2GiB"}, nil},
+		{"MemoryTerabytes", typst.Docker{Memory: "1t"}, nil},
+		{"Limits", typst.Docker{CPUs: -1, PidsLimit: -2}, []string{"CPUs", "PidsLimit"}},
+		{"Unlimited", typst.Docker{PidsLimit: -1}.Hardened(), nil},
+		{"CapDrop", typst.Docker{CapDrop: []string{"ALL", "--privileged"}}, []string{"CapDrop"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := validationFields(t, tt.caller.Validate())
+			if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("Got validation errors for %q, want %q.", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestDocker_ValidateBeforeRun(t *testing.T) {
+	logPath := fakeRuntime(t, "docker-hardened", `echo "typst 0.14.0 (b790c6d5)"`)
+
+	typstCaller := typst.Docker{Executable: "docker-hardened", Memory: "lots"}
+
+	var errValidation *typst.ValidationError
+	if _, err := typstCaller.VersionString(); !errors.As(err, &errValidation) {
+		t.Errorf("Expected error of type %T, got %T: %v.", errValidation, err, err)
+	}
+	if _, err := os.Stat(logPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the container runtime not to be invoked.")
+	}
+}
+
+func TestDocker_WorkspacePermissions(t *testing.T) {
+	permsPath := filepath.Join(t.TempDir(), "perms.log")
+	fakeRuntime(t, "docker", `for arg in "$@"; do
+	case "$arg" in
+	*:/go-typst/workspace-*) dir="${arg%%:/go-typst/*}"; ls -ld "$dir" | cut -c1-10 >> `+permsPath+`; ls -ld "$(dirname "$dir")" | cut -c1-10 >> `+permsPath+`;;
+	esac
+done`)
+
+	// The workspace must be writable for the user in the container, but unreachable for other users on the host.
+	outputPath := filepath.Join(t.TempDir(), "output.pdf")
+	if err := (typst.Docker{}).Compile(strings.NewReader("Hello"), typst.OutputFile(outputPath), nil); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+
+	content, err := os.ReadFile(permsPath)
+	if err != nil {
+		t.Fatalf("Failed to read permissions: %v.", err)
+	}
+	if got, want := strings.Fields(string(content)), []string{"drwxrwxrwt", "drwx------"}; !slices.Equal(got, want) {
+		t.Errorf("Unexpected permissions. Got %q, want %q.", got, want)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// DockerImageRepository is the repository of the official Typst Docker images.
+const DockerImageRepository = "ghcr.io/typst/typst"
+
+// DockerPullPolicy defines when the container runtime pulls the image before a container is started.
+type DockerPullPolicy string
+
+const (
+	DockerPullDefault DockerPullPolicy = ""        // Use the default of the container runtime, which is usually typst.DockerPullMissing.
+	DockerPullMissing DockerPullPolicy = "missing" // Pull the image only if it isn't present locally.
+	DockerPullNever   DockerPullPolicy = "never"   // Never pull the image. Invocations fail with a *typst.DockerError of kind typst.DockerErrorImageNotFound if it isn't present.
+	DockerPullAlways  DockerPullPolicy = "always"  // Always pull the image before a container is started.
+)
+
+// DockerImageForVersion returns the official Typst Docker image for the given Typst version.
+//
+// Images before Typst 0.14.0 are tagged with a "v" prefix, like "ghcr.io/typst/typst:v0.13.1".
+// Newer images are tagged with the plain version, like "ghcr.io/typst/typst:0.14.0".
+func DockerImageForVersion(version Version) string {
+	if !version.AtLeast(0, 14, 0) {
+		return DockerImageRepository + ":v" + version.String()
+	}
+	return DockerImageRepository + ":" + version.String()
+}
+
+// dockerImageName returns the image reference without tag and digest, like "ghcr.io/typst/typst".
+func dockerImageName(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+
+	// A colon after the last slash separates the tag, any other colon belongs to the port of the registry.
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// image returns the image to use.
+func (d Docker) image() string {
+	if d.Image != "" {
+		return d.Image
+	}
+	return DockerDefaultImage
+}
+
+// ImagePresent returns whether the image is present locally, so that no pull is necessary to start a container.
+func (d Docker) ImagePresent(ctx context.Context) (bool, error) {
+	if _, err := docker(ctx, d.Executable, "image", "inspect", "--format", "{{.Id}}", d.image()); err != nil {
+		var dockerErr *DockerError
+		if errors.As(err, &dockerErr) && dockerErr.Kind == DockerErrorImageNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// PullImage pulls the image, even if it's already present locally.
+//
+// The progress of the pull is written to progress as it is reported by the container runtime.
+// The progress parameter is optional, and can be nil.
+func (d Docker) PullImage(ctx context.Context, progress io.Writer) error {
+	executable := dockerExecutable(d.Executable)
+
+	cmd := exec.CommandContext(ctx, executable, "pull", d.image())
+	cmd.Stdout = progress
+
+	var errBuffer strings.Builder
+	cmd.Stderr = &errBuffer
+	if progress != nil {
+		cmd.Stderr = io.MultiWriter(progress, &errBuffer)
+	}
+
+	if err := cmd.Run(); err != nil {
+		dockerErr := newDockerError(err, errBuffer.String())
+		if dockerErr.Kind == DockerErrorUnknown && ctx.Err() == nil {
+			// Any failure of docker pull is a failed pull, unless it's known to be something else.
+			dockerErr.Kind = DockerErrorPullFailed
+		}
+		return fmt.Errorf("failed to pull image %s: %w", d.image(), dockerErr)
+	}
+
+	return nil
+}
+
+// EnsureImage pulls the image if it isn't present locally.
+//
+// Call this on startup to pre-warm the image, so that no invocation has to wait for a pull.
+// The progress parameter is optional, and can be nil. See PullImage.
+func (d Docker) EnsureImage(ctx context.Context, progress io.Writer) error {
+	present, err := d.ImagePresent(ctx)
+	if err != nil {
+		return err
+	}
+	if present {
+		return nil
+	}
+
+	return d.PullImage(ctx, progress)
+}
+
+// PinnedImage returns the image reference pinned to the digest of the local image, like "ghcr.io/typst/typst@sha256:...".
+//
+// The result can be used as Image, so that all invocations use exactly this image, even if the tag gets moved.
+// The image has to be present locally, see EnsureImage.
+func (d Docker) PinnedImage(ctx context.Context) (string, error) {
+	output, err := docker(ctx, d.Executable, "image", "inspect", "--format", "{{range .RepoDigests}}{{println .}}{{end}}", d.image())
+	if err != nil {
+		return "", err
+	}
+
+	name := dockerImageName(d.image())
+	for _, repoDigest := range strings.Fields(output) {
+		if dockerImageName(repoDigest) == name {
+			return repoDigest, nil
+		}
+	}
+
+	return "", fmt.Errorf("image %s has no digest of repository %s, it may not have been pulled from a registry", d.image(), name)
+}
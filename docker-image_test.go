@@ -0,0 +1,129 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+//go:build unix
+
+package typst_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Dadido3/go-typst"
+)
+
+func TestDockerImageForVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"0.12.0", "ghcr.io/typst/typst:v0.12.0"},
+		{"0.13.1", "ghcr.io/typst/typst:v0.13.1"},
+		{"0.14.0", "ghcr.io/typst/typst:0.14.0"},
+		{"typst 0.14.0 (b790c6d5)", "ghcr.io/typst/typst:0.14.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			version, err := typst.ParseVersion(tt.version)
+			if err != nil {
+				t.Fatalf("Failed to parse version: %v.", err)
+			}
+			if got := typst.DockerImageForVersion(version); got != tt.want {
+				t.Errorf("Got %q, want %q.", got, tt.want)
+			}
+		})
+	}
+
+	// The default image must follow the same scheme.
+	version, err := typst.ParseVersion(strings.TrimPrefix(typst.DockerDefaultImage, typst.DockerImageRepository+":"))
+	if err != nil {
+		t.Fatalf("Failed to parse version of default image: %v.", err)
+	}
+	if got := typst.DockerImageForVersion(version); got != typst.DockerDefaultImage {
+		t.Errorf("Got %q, want %q.", got, typst.DockerDefaultImage)
+	}
+}
+
+// fakeImageRuntime is a fake container runtime that only knows the image "present:1.0".
+const fakeImageRuntime = `case "$1" in
+image)
+	eval "image=\${$#}"
+	[ "$image" = present:1.0 ] || { echo "Error response from daemon: No such image: $image" >&2; exit 1; }
+	printf 'other@sha256:0000\npresent@sha256:1234\n';;
+pull)
+	[ "$2" = present:1.0 ] || { echo "Error response from daemon: failed to resolve reference \"$2\": not found" >&2; exit 1; }
+	echo "1.0: Pulling from present"; echo "Status: Downloaded newer image for $2";;
+esac`
+
+func TestDocker_EnsureImage(t *testing.T) {
+	logPath := fakeRuntime(t, "docker-image", fakeImageRuntime)
+
+	typstCaller := typst.Docker{Executable: "docker-image", Image: "present:1.0"}
+
+	// The image is present, so nothing is pulled.
+	if err := typstCaller.EnsureImage(context.Background(), nil); err != nil {
+		t.Fatalf("Failed to ensure image: %v.", err)
+	}
+	if calls := readCalls(t, logPath); len(calls) != 1 || !strings.HasPrefix(calls[0], "image inspect ") {
+		t.Errorf("Unexpected invocations %q.", calls)
+	}
+
+	var progress bytes.Buffer
+	if err := typstCaller.PullImage(context.Background(), &progress); err != nil {
+		t.Fatalf("Failed to pull image: %v.", err)
+	}
+	if !strings.Contains(progress.String(), "Status: Downloaded newer image") {
+		t.Errorf("Unexpected progress output %q.", progress.String())
+	}
+
+	pinned, err := typstCaller.PinnedImage(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get pinned image: %v.", err)
+	}
+	if want := "present@sha256:1234"; pinned != want {
+		t.Errorf("Got pinned image %q, want %q.", pinned, want)
+	}
+}
+
+func TestDocker_EnsureImageMissing(t *testing.T) {
+	logPath := fakeRuntime(t, "docker-image", fakeImageRuntime)
+
+	typstCaller := typst.Docker{Executable: "docker-image", Image: "missing:1.0"}
+
+	present, err := typstCaller.ImagePresent(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to check image: %v.", err)
+	}
+	if present {
+		t.Errorf("Expected image to be missing.")
+	}
+
+	err = typstCaller.EnsureImage(context.Background(), nil)
+	var errDocker *typst.DockerError
+	if !errors.As(err, &errDocker) || errDocker.Kind != typst.DockerErrorPullFailed {
+		t.Errorf("Expected error of kind %q, got %v.", typst.DockerErrorPullFailed, err)
+	}
+
+	if calls := readCalls(t, logPath); len(calls) != 3 || calls[2] != "pull missing:1.0" {
+		t.Errorf("Unexpected invocations %q.", calls)
+	}
+}
+
+func TestDocker_PullPolicy(t *testing.T) {
+	logPath := fakeRuntime(t, "docker-image", `echo "typst 0.14.0 (b790c6d5)"`)
+
+	typstCaller := typst.Docker{Executable: "docker-image", PullPolicy: typst.DockerPullNever}
+	if _, err := typstCaller.VersionString(); err != nil {
+		t.Fatalf("Failed to get typst version: %v.", err)
+	}
+
+	if calls := readCalls(t, logPath); len(calls) != 1 || !strings.Contains(calls[0], " --pull never ") {
+		t.Errorf("Invocations %q don't contain %q.", calls, " --pull never ")
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+//go:build unix
+
+package typst_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dadido3/go-typst"
+)
+
+func TestDockerContainer_Restart(t *testing.T) {
+	stoppedPath := filepath.Join(t.TempDir(), "stopped")
+	logPath := fakeRuntime(t, "docker-restart", `case "$1" in
+run) rm -f `+stoppedPath+` ;;
+exec) [ ! -e `+stoppedPath+` ] || { echo 'Error response from daemon: container abc is not running' >&2; exit 1; }; echo "typst 0.14.0 (b790c6d5)" ;;
+esac`)
+
+	typstCaller := &typst.DockerContainer{Executable: "docker-restart"}
+	defer typstCaller.Close()
+
+	for range 2 {
+		if _, err := typstCaller.VersionString(); err != nil {
+			t.Fatalf("Failed to get typst version: %v.", err)
+		}
+	}
+	name := typstCaller.ContainerName()
+
+	// The container is only checked once when it's started, and not before every invocation.
+	calls := readCalls(t, logPath)
+	if len(calls) != 4 {
+		t.Fatalf("Unexpected number of invocations. Got %d, want %d: %q.", len(calls), 4, calls)
+	}
+	for i, prefix := range []string{"run ", "exec ", "exec ", "exec "} {
+		if !strings.HasPrefix(calls[i], prefix) {
+			t.Errorf("Unexpected invocation %q, want prefix %q.", calls[i], prefix)
+		}
+	}
+
+	// The label allows orphaned containers to be removed by SweepContainers.
+	if want := " --label " + typst.DockerLabel + "=container "; !strings.Contains(calls[0], want) {
+		t.Errorf("Invocation %q doesn't contain %q.", calls[0], want)
+	}
+
+	// Stop the container, the failed invocation has to be repeated in a new container.
+	if err := os.WriteFile(stoppedPath, nil, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v.", err)
+	}
+	if _, err := typstCaller.VersionString(); err != nil {
+		t.Fatalf("Failed to get typst version: %v.", err)
+	}
+
+	calls = readCalls(t, logPath)
+	if len(calls) != 9 {
+		t.Fatalf("Unexpected number of invocations. Got %d, want %d: %q.", len(calls), 9, calls)
+	}
+	for i, prefix := range []string{"exec ", "rm " + name, "run ", "exec ", "exec "} {
+		if !strings.HasPrefix(calls[4+i], prefix) {
+			t.Errorf("Unexpected invocation %q, want prefix %q.", calls[4+i], prefix)
+		}
+	}
+	for _, call := range calls {
+		if strings.HasPrefix(call, "rm --force") {
+			t.Errorf("The container must not be removed forcefully, got %q.", call)
+		}
+	}
+}
+
+func TestDockerContainer_RestartInput(t *testing.T) {
+	stoppedPath := filepath.Join(t.TempDir(), "stopped")
+	fakeRuntime(t, "docker-restart-input", `[ "$1" = exec ] || exit 0
+case "$*" in
+*--version*) echo "typst 0.14.0 (b790c6d5)" ;;
+*) if [ -e `+stoppedPath+` ]; then cat > /dev/null; rm `+stoppedPath+`; echo 'Error response from daemon: container abc is not running' >&2; exit 1; fi; cat ;;
+esac`)
+
+	hooks := &recordingHooks{}
+	typstCaller := &typst.DockerContainer{Executable: "docker-restart-input", Hooks: hooks}
+	defer typstCaller.Close()
+
+	if err := typstCaller.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start container: %v.", err)
+	}
+
+	// The first attempt consumes the input, the repeated one must still get all of it.
+	if err := os.WriteFile(stoppedPath, nil, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v.", err)
+	}
+	var w bytes.Buffer
+	if err := typstCaller.Compile(strings.NewReader("Hello"), &w, nil); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+	if w.String() != "Hello" {
+		t.Errorf("Unexpected output %q, want %q.", w.String(), "Hello")
+	}
+
+	// The error of the failed attempt must not end up in the stderr of the invocation.
+	for _, info := range hooks.finished {
+		if strings.Contains(info.Stderr, "not running") {
+			t.Errorf("Unexpected stderr %q of invocation %q.", info.Stderr, info.Args)
+		}
+	}
+}
+
+func TestDockerExec_Cancel(t *testing.T) {
+	logPath := fakeRuntime(t, "docker-cancel", `[ "$1" = kill ] || exec sleep 10`)
+
+	typstCaller := typst.DockerExec{Executable: "docker-cancel", ContainerName: "typst-instance"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	if _, err := typstCaller.VersionStringWithContext(ctx); err == nil {
+		t.Fatalf("Expected error, but got nil.")
+	}
+
+	// Typst is invoked directly, and the whole container is killed.
+	calls := readCalls(t, logPath)
+	if want := []string{"exec -i typst-instance typst --version", "kill typst-instance"}; !slices.Equal(calls, want) {
+		t.Errorf("Unexpected invocations %q, want %q.", calls, want)
+	}
+}
+
+func TestDockerExec_CancelKillProcess(t *testing.T) {
+	logPath := fakeRuntime(t, "docker-cancel", `case "$*" in *pkill*) ;; *) exec sleep 10 ;; esac`)
+
+	typstCaller := typst.DockerExec{Executable: "docker-cancel", ContainerName: "typst-instance", KillProcess: true}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	if _, err := typstCaller.VersionStringWithContext(ctx); err == nil {
+		t.Fatalf("Expected error, but got nil.")
+	}
+
+	calls := readCalls(t, logPath)
+	if len(calls) != 2 {
+		t.Fatalf("Unexpected number of invocations. Got %d, want %d: %q.", len(calls), 2, calls)
+	}
+
+	var marker string
+	for _, field := range strings.Fields(calls[0]) {
+		if strings.HasPrefix(field, "go-typst-invocation-") {
+			marker = field
+		}
+	}
+	if marker == "" {
+		t.Fatalf("Invocation %q doesn't contain a marker.", calls[0])
+	}
+
+	// Only the Typst process of the invocation is killed, not the whole container.
+	if !strings.HasPrefix(calls[1], "exec typst-instance ") || !strings.HasSuffix(calls[1], " [g]"+marker[1:]) {
+		t.Errorf("Unexpected invocation %q, want it to kill the process with the marker %q.", calls[1], marker)
+	}
+}
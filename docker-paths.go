@@ -0,0 +1,278 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// dockerHostDir is the directory inside of the container where translated host paths are mounted.
+const dockerHostDir = "/go-typst/host"
+
+// pathMount is a host directory that is bind-mounted into the container.
+type pathMount struct {
+	host      string // The absolute path on the host.
+	container string // The path inside of the container.
+	writable  bool
+}
+
+// pathTranslator translates host paths into paths inside of a container, and collects the mounts that are necessary for that.
+type pathTranslator struct {
+	base   string // The host directory that relative paths are resolved against.
+	mounts []pathMount
+}
+
+// newPathTranslator returns a pathTranslator that resolves relative paths against workingDirectory, or against the current working directory if it's empty.
+func newPathTranslator(workingDirectory string) (*pathTranslator, error) {
+	base, err := filepath.Abs(workingDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path of working directory: %w", err)
+	}
+
+	return &pathTranslator{base: base}, nil
+}
+
+// containerPath returns the path inside of the container for the given absolute host path.
+//
+// The host path is mirrored below dockerHostDir, so that relative paths between files stay the same.
+// On Windows, the volume name becomes the first path element, like "/go-typst/host/C/Users".
+func containerPath(hostPath string) string {
+	volume := filepath.VolumeName(hostPath)
+	rest := filepath.ToSlash(hostPath[len(volume):])
+
+	volume = strings.Trim(strings.ReplaceAll(filepath.ToSlash(volume), ":", ""), "/")
+
+	return path.Join(dockerHostDir, volume, rest)
+}
+
+// abs returns the absolute host path of p.
+func (t *pathTranslator) abs(p string) string {
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(t.base, p)
+}
+
+// dir mounts the host directory p, and returns its path inside of the container.
+// Empty paths are returned as is.
+func (t *pathTranslator) dir(p string, writable bool) string {
+	if p == "" {
+		return ""
+	}
+
+	hostPath := t.abs(p)
+	mount := pathMount{host: hostPath, container: containerPath(hostPath), writable: writable}
+	if !slices.Contains(t.mounts, mount) {
+		t.mounts = append(t.mounts, mount)
+	}
+
+	return mount.container
+}
+
+// dirs mounts all host directories in paths, and returns their paths inside of the container.
+func (t *pathTranslator) dirs(paths []string) []string {
+	if paths == nil {
+		return nil
+	}
+
+	result := make([]string, 0, len(paths))
+	for _, p := range paths {
+		result = append(result, t.dir(p, false))
+	}
+	return result
+}
+
+// file mounts the directory that contains the host file p, and returns the path of the file inside of the container.
+func (t *pathTranslator) file(p string) string {
+	hostPath := t.abs(p)
+	t.dir(filepath.Dir(hostPath), false)
+
+	return containerPath(hostPath)
+}
+
+// input translates typst.InputFile into a path inside of the container.
+// Any other reader is returned as is.
+func (t *pathTranslator) input(input io.Reader) io.Reader {
+	if file, ok := input.(InputFile); ok {
+		return InputFile(t.file(string(file)))
+	}
+	return input
+}
+
+// volumes returns all volumes that need to be mounted.
+// Directories that are already contained in another mount with the same access are omitted.
+func (t *pathTranslator) volumes() []string {
+	mounts := slices.Clone(t.mounts)
+	slices.SortFunc(mounts, func(a, b pathMount) int { return strings.Compare(a.host, b.host) })
+
+	var result []string
+	var kept []pathMount
+	for _, mount := range mounts {
+		if slices.ContainsFunc(kept, func(parent pathMount) bool {
+			return parent.writable == mount.writable && isSubPath(parent.host, mount.host)
+		}) {
+			continue
+		}
+		kept = append(kept, mount)
+
+		volume := mount.host + ":" + mount.container
+		if !mount.writable {
+			volume += ":ro"
+		}
+		result = append(result, volume)
+	}
+
+	return result
+}
+
+// isSubPath returns whether child is equal to or inside of parent.
+func isSubPath(parent, child string) bool {
+	rel, err := filepath.Rel(parent, child)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// hostPath returns the host path of the given path inside of the container.
+// Paths that are not inside of a mount are returned as is.
+func (t *pathTranslator) hostPath(p string) string {
+	for _, mount := range t.mounts {
+		if p == mount.container {
+			return mount.host
+		}
+		if rest, ok := strings.CutPrefix(p, mount.container+"/"); ok {
+			return filepath.Join(mount.host, filepath.FromSlash(rest))
+		}
+	}
+	return p
+}
+
+// details returns a copy of details with all paths translated back to host paths.
+func (t *pathTranslator) details(details []ErrorDetails) []ErrorDetails {
+	if details == nil {
+		return nil
+	}
+
+	result := slices.Clone(details)
+	for i := range result {
+		result[i].Path = t.hostPath(result[i].Path)
+	}
+	return result
+}
+
+// warningHandler returns a warning handler that translates all paths back to host paths before passing them to handler.
+func (t *pathTranslator) warningHandler(handler func(warnings []ErrorDetails)) func(warnings []ErrorDetails) {
+	if handler == nil {
+		return nil
+	}
+	return func(warnings []ErrorDetails) {
+		handler(t.details(warnings))
+	}
+}
+
+// error translates the paths of a *typst.Error back to host paths.
+// Any other error is returned as is.
+func (t *pathTranslator) error(err error) error {
+	var typstErr *Error
+	if !errors.As(err, &typstErr) {
+		return err
+	}
+
+	translated := *typstErr
+	translated.Details = t.details(typstErr.Details)
+	return &translated
+}
+
+// environment returns a copy of env with all host paths translated.
+func (t *pathTranslator) environment(env *Environment) *Environment {
+	if env == nil {
+		return nil
+	}
+
+	envCopy := *env
+	envCopy.FontPaths = t.dirs(env.FontPaths)
+	envCopy.PackagePath = t.dir(env.PackagePath, false)
+	envCopy.PackageCachePath = t.dir(env.PackageCachePath, true)
+	if env.Cert != "" {
+		envCopy.Cert = t.file(env.Cert)
+	}
+
+	return &envCopy
+}
+
+// docker returns a copy of d that mounts all collected volumes, and that runs Typst in the translated working directory.
+// With this, paths that Typst reports relative to its working directory are the same as on the host.
+// The paths of the environment of d are translated as well.
+func (t *pathTranslator) docker(d Docker) Docker {
+	d.Environment = t.environment(d.Environment)
+	d.Volumes = slices.Concat(d.Volumes, t.volumes())
+	d.Custom = slices.Concat(d.Custom, []string{"--workdir", containerPath(t.base)})
+	return d
+}
+
+// fonts returns a copy of options with all host paths translated.
+func (t *pathTranslator) fonts(options *OptionsFonts) *OptionsFonts {
+	if options == nil {
+		return nil
+	}
+
+	optionsCopy := *options
+	optionsCopy.FontPaths = t.dirs(options.FontPaths)
+
+	return &optionsCopy
+}
+
+// compile returns a copy of input and options with all host paths translated.
+// Files of a project are not translated, as they are copied into their own workspace.
+func (t *pathTranslator) compile(input io.Reader, options *OptionsCompile) (io.Reader, *OptionsCompile) {
+	var optionsCopy OptionsCompile
+	if options != nil {
+		optionsCopy = *options
+	}
+
+	_, isFile := input.(InputFile)
+	if optionsCopy.Project == nil && len(optionsCopy.Files) == 0 {
+		input = t.input(input)
+		if optionsCopy.Root == "" && !isFile {
+			// Typst uses the working directory as root.
+			t.dir(t.base, false)
+		}
+	}
+
+	optionsCopy.Root = t.dir(optionsCopy.Root, false)
+	optionsCopy.FontPaths = t.dirs(optionsCopy.FontPaths)
+	optionsCopy.PackagePath = t.dir(optionsCopy.PackagePath, false)
+	optionsCopy.PackageCachePath = t.dir(optionsCopy.PackageCachePath, true) // Typst downloads packages into the cache.
+	optionsCopy.WarningHandler = t.warningHandler(optionsCopy.WarningHandler)
+
+	return input, &optionsCopy
+}
+
+// query returns a copy of input and options with all host paths translated.
+func (t *pathTranslator) query(input io.Reader, options *OptionsQuery) (io.Reader, *OptionsQuery) {
+	if options == nil {
+		return input, nil
+	}
+	optionsCopy := *options
+
+	if _, isFile := input.(InputFile); !isFile && optionsCopy.Root == "" {
+		// Typst uses the working directory as root.
+		t.dir(t.base, false)
+	}
+	input = t.input(input)
+
+	optionsCopy.Root = t.dir(optionsCopy.Root, false)
+	optionsCopy.FontPaths = t.dirs(optionsCopy.FontPaths)
+	optionsCopy.PackagePath = t.dir(optionsCopy.PackagePath, false)
+	optionsCopy.PackageCachePath = t.dir(optionsCopy.PackageCachePath, true)
+	optionsCopy.WarningHandler = t.warningHandler(optionsCopy.WarningHandler)
+
+	return input, &optionsCopy
+}
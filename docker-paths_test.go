@@ -0,0 +1,88 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+//go:build unix
+
+package typst_test
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dadido3/go-typst"
+)
+
+func TestDocker_TranslatePaths(t *testing.T) {
+	root := t.TempDir()
+	fonts := t.TempDir()
+	cache := t.TempDir()
+
+	logPath := fakeRuntime(t, "docker-paths", `echo '/go-typst/host`+root+`/chapter.typ:2:3: error: unknown variable: foo' >&2; exit 1`)
+
+	typstCaller := typst.Docker{
+		Executable:       "docker-paths",
+		WorkingDirectory: root,
+		TranslatePaths:   true,
+	}
+	options := typst.OptionsCompile{
+		FontPaths:        []string{fonts},
+		PackageCachePath: cache,
+	}
+
+	var w bytes.Buffer
+	err := typstCaller.Compile(typst.InputFile("main.typ"), &w, &options)
+
+	var errTypst *typst.Error
+	if !errors.As(err, &errTypst) {
+		t.Fatalf("Expected error of type %T, got %T: %v.", errTypst, err, err)
+	}
+	if len(errTypst.Details) != 1 || errTypst.Details[0].Path != filepath.Join(root, "chapter.typ") {
+		t.Errorf("Unexpected error details %+v, want the path to be mapped to %q.", errTypst.Details, filepath.Join(root, "chapter.typ"))
+	}
+
+	// The options of the caller must not be modified.
+	if options.FontPaths[0] != fonts || options.PackageCachePath != cache {
+		t.Errorf("Options have been modified: %+v.", options)
+	}
+
+	calls := readCalls(t, logPath)
+	if len(calls) != 1 {
+		t.Fatalf("Unexpected number of invocations. Got %d, want %d.", len(calls), 1)
+	}
+	for _, want := range []string{
+		" -v " + root + ":/go-typst/host" + root + ":ro ",
+		" -v " + fonts + ":/go-typst/host" + fonts + ":ro ",
+		" -v " + cache + ":/go-typst/host" + cache + " ",
+		" --workdir /go-typst/host" + root + " ",
+		" --font-path /go-typst/host" + fonts + " ",
+		" --package-cache-path /go-typst/host" + cache + " ",
+		" /go-typst/host" + root + "/main.typ -",
+	} {
+		if !strings.Contains(calls[0], want) {
+			t.Errorf("Invocation %q doesn't contain %q.", calls[0], want)
+		}
+	}
+}
+
+func TestDocker_TranslatePathsStdin(t *testing.T) {
+	root := t.TempDir()
+	logPath := fakeRuntime(t, "docker-paths", `cat > /dev/null; printf 'output'`)
+
+	typstCaller := typst.Docker{Executable: "docker-paths", WorkingDirectory: root, TranslatePaths: true}
+
+	var w bytes.Buffer
+	if err := typstCaller.Compile(bytes.NewBufferString(`#include "chapter.typ"`), &w, nil); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+
+	// Typst uses the working directory as root, so it has to be mounted.
+	calls := readCalls(t, logPath)
+	if want := " -v " + root + ":/go-typst/host" + root + ":ro "; len(calls) != 1 || !strings.Contains(calls[0], want) {
+		t.Errorf("Invocations %q don't contain %q.", calls, want)
+	}
+}
@@ -6,29 +6,74 @@
 package typst
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os/exec"
+	"strings"
+	"sync"
+	"time"
 )
 
 // Theoretically it's possible to use the Docker SDK directly:
 // https://docs.docker.com/reference/api/engine/sdk/examples/
 // But that dependency is unnecessarily huge, therefore we will just call the Docker executable.
 
+// The default container runtime executable to use.
+const DockerDefaultExecutable = "docker"
+
 // The default Docker image to use.
 // This is the latest supported version of Typst.
 const DockerDefaultImage = "ghcr.io/typst/typst:0.14.0"
 
+// DockerLabel is the label that is added to all containers that are started by typst.Docker and typst.DockerContainer.
+// It is used to find orphaned containers, see Docker.SweepContainers.
+const DockerLabel = "com.github.dadido3.go-typst"
+
 // Docker allows you to invoke commands on a Typst Docker image.
 //
 // This uses docker run to automatically pull and run a container.
 // Therefore the container will start and stop automatically.
+// The image can also be pulled ahead of time, see EnsureImage.
+// Every container has a unique name and the label typst.DockerLabel, and is removed once the invocation has finished, was aborted or has panicked.
+// If the process is killed during an invocation, the container may be left behind, see Docker.SweepContainers.
 // To have more control over the lifetime of a Docker container see typst.DockerExec.
 type Docker struct {
+	Executable       string // The container runtime executable, like "docker", "podman" or "nerdctl". Defaults to typst.DockerDefaultExecutable if left empty.
 	Image            string // The image to use, defaults to the latest supported official Typst Docker image if left empty. See: typst.DockerDefaultImage.
 	WorkingDirectory string // The working directory of Docker. When left empty, Docker will be run with the process's current working directory.
+	UserNamespace    string // The user namespace mode that is passed via "--userns", like "keep-id" for rootless Podman. When left empty, the default of the runtime is used.
+	SELinuxRelabel   bool   // Adds the "Z" option to all volumes except anonymous ones, including internally created ones, so that they are relabeled for use on SELinux enabled hosts.
+
+	// Defines when the image is pulled before a container is started.
+	// Set this to typst.DockerPullNever together with EnsureImage on startup, so that invocations never wait for a pull.
+	PullPolicy DockerPullPolicy
+
+	// Hardening and resource limits of the container, see Hardened for a preset.
+	// All of them are disabled when left empty.
+	Network         string   // The network that the container is connected to, passed via "--network". Use "none" to disable network access.
+	ReadOnly        bool     // Mounts the root filesystem of the container as read-only via "--read-only".
+	Tmpfs           []string // Directories inside of the container that are mounted as tmpfs via "--tmpfs", like "/tmp". Useful together with ReadOnly.
+	Memory          string   // The memory limit passed via "--memory", like "512m", "1.5g" or "2GiB".
+	CPUs            float64  // The number of CPUs passed via "--cpus", like 1.5.
+	PidsLimit       int      // The maximum number of processes and threads passed via "--pids-limit". Use -1 for no limit, which overrides the limit of Hardened.
+	CapDrop         []string // Linux capabilities that are dropped via "--cap-drop", like "ALL".
+	NoNewPrivileges bool     // Prevents processes from gaining new privileges via "--security-opt no-new-privileges".
+	CurrentUser     bool     // Runs Typst as the current uid:gid via "--user", so that written files are not owned by root. Not supported on Windows.
+
+	// Mounts the host paths of all options into the container automatically, so that typst.Docker can be used like typst.CLI.
+	//
+	// The paths of OptionsCompile.Root, FontPaths, PackagePath, PackageCachePath, typst.InputFile and of the Environment are resolved relative to WorkingDirectory.
+	// They are bind-mounted read-only below "/go-typst/host" inside of the container, and the options are rewritten to these paths.
+	// The package cache is mounted writable, so Typst can download packages into it.
+	// If the document is passed via stdin without Root, the working directory is mounted as root.
+	// Paths in the details of a returned *typst.Error, or in warnings, are mapped back to host paths.
+	//
+	// This has no effect on Watch.
+	TranslatePaths bool
+	Environment    *Environment // Optional environment variables of Typst, which are passed into the container via a private "--env-file".
+	Hooks          Hooks        // Optional hooks that are called before and after every invocation of Typst, see typst.SlogHooks.
 
 	// Additional bind-mounts or volumes that are passed via "--volume" flag to Docker.
 	// For details, see: https://docs.docker.com/engine/storage/volumes/#syntax
@@ -46,121 +91,395 @@ type Docker struct {
 	Custom []string // Custom "docker run" command line options go here.
 }
 
-// Ensure that Docker implements the Caller interface.
-var _ Caller = Docker{}
+// Ensure that Docker implements the ContextCaller and QueryCaller interfaces.
+var (
+	_ ContextCaller = Docker{}
+	_ QueryCaller   = Docker{}
+)
 
 // args returns docker related arguments.
-func (d Docker) args() []string {
-	image := DockerDefaultImage
-	if d.Image != "" {
-		image = d.Image
-	}
-
+// The envArgs are the arguments that pass the environment variables, see Environment.envFile.
+func (d Docker) args(containerName string, envArgs, volumes []string) []string {
 	// Argument -i is needed for stdio to work.
 	args := []string{"run", "-i"}
 
+	// Name the container, so that we can kill it when the invocation gets canceled.
+	args = append(args, "--name", containerName)
+
+	// Remove the container once it has stopped, and label it so that orphans can be found.
+	args = append(args, "--rm", "--label", DockerLabel+"=run")
+
+	if d.UserNamespace != "" {
+		args = append(args, "--userns", d.UserNamespace)
+	}
+
+	if d.PullPolicy != DockerPullDefault {
+		args = append(args, "--pull", string(d.PullPolicy))
+	}
+
+	args = append(args, d.hardeningArgs()...)
+	args = append(args, envArgs...)
+
 	args = append(args, d.Custom...)
 
 	// Add mounts.
 	for _, volume := range d.Volumes {
-		args = append(args, "-v", volume)
+		args = append(args, "-v", volumeWithLabel(volume, d.SELinuxRelabel))
+	}
+	for _, volume := range volumes {
+		args = append(args, "-v", volumeWithLabel(volume, d.SELinuxRelabel))
 	}
 
 	// Which docker image to use.
-	args = append(args, image)
+	args = append(args, d.image())
 
 	return args
 }
 
-// VersionString returns the Typst version as a string.
-func (d Docker) VersionString() (string, error) {
-	args := append(d.args(), "--version")
+// run implements the runner interface.
+func (d Docker) run(ctx context.Context, inv *invocation) error {
+	if err := d.Validate(); err != nil {
+		return fmt.Errorf("invalid Docker configuration: %w", err)
+	}
 
-	cmd := exec.Command("docker", args...)
+	envArgs, removeEnvFile, err := d.Environment.envFile()
+	if err != nil {
+		return err
+	}
+	defer removeEnvFile()
 
-	var output, errBuffer bytes.Buffer
-	cmd.Stdout = &output
-	cmd.Stderr = &errBuffer
+	containerName := "go-typst-" + randomID()
+	args := d.args(containerName, envArgs, inv.volumes)
+	args = append(args, inv.args...)
 
-	if err := cmd.Run(); err != nil {
-		switch err := err.(type) {
-		case *exec.ExitError:
-			return "", ParseStderr(errBuffer.String(), err)
-		default:
-			return "", err
+	executable := dockerExecutable(d.Executable)
+
+	// Stopping the Docker CLI doesn't necessarily stop the container, so we kill and remove it explicitly.
+	kill := func() {
+		exec.Command(executable, "rm", "--force", containerName).Run() //nolint:errcheck
+	}
+
+	// Also remove the container if a panic unwinds the stack.
+	finished := false
+	defer func() {
+		if !finished {
+			kill()
 		}
+	}()
+
+	// Panics of the input and output are caught, and repeated in this goroutine.
+	trap := &panicTrap{onPanic: kill}
+
+	cmd := commandContext(ctx, kill, executable, args...)
+	cmd.Dir = d.WorkingDirectory
+	cmd.Stdin = trap.reader(inv.stdin)
+	cmd.Stdout = trap.writer(inv.stdout)
+
+	var errBuffer bytes.Buffer
+	cmd.Stderr = trap.writer(io.MultiWriter(inv.stderr, &errBuffer))
+
+	err = cmd.Run()
+	trap.repanic()
+	finished = true
+
+	if err != nil {
+		return dockerInvocationError(err, errBuffer.String())
 	}
 
-	return output.String(), nil
+	return nil
 }
 
-// Fonts returns all fonts that are available to Typst.
-// The options parameter is optional, and can be nil.
-func (d Docker) Fonts(options *OptionsFonts) ([]string, error) {
-	args := d.args()
+// panicTrap catches panics of the readers and writers that are connected to a command.
+//
+// exec.Cmd calls them from its own goroutines, where a panic would crash the process without unwinding the stack of the invocation.
+type panicTrap struct {
+	onPanic func() // Called once the first panic is caught, so that the command can be stopped.
 
-	if options == nil {
-		options = new(OptionsFonts)
+	once     sync.Once
+	panicked bool
+	value    any
+}
+
+// catch recovers a panic, and turns it into an error.
+// It must be deferred directly.
+func (p *panicTrap) catch(err *error) {
+	if r := recover(); r != nil {
+		p.once.Do(func() {
+			p.panicked, p.value = true, r
+			p.onPanic()
+		})
+		*err = fmt.Errorf("panic during I/O of the invocation: %v", r)
 	}
-	args = append(args, options.Args()...)
+}
 
-	cmd := exec.Command("docker", args...)
+// repanic repeats the first caught panic in the calling goroutine.
+// It must only be called once the command has finished.
+func (p *panicTrap) repanic() {
+	if p.panicked {
+		panic(p.value)
+	}
+}
+
+// reader returns r with its panics caught.
+func (p *panicTrap) reader(r io.Reader) io.Reader {
+	if r == nil {
+		return nil
+	}
+	return trappedReader{trap: p, r: r}
+}
+
+// writer returns w with its panics caught.
+func (p *panicTrap) writer(w io.Writer) io.Writer {
+	if w == nil {
+		return nil
+	}
+	return trappedWriter{trap: p, w: w}
+}
+
+// trappedReader is an io.Reader whose panics are caught by a panicTrap.
+type trappedReader struct {
+	trap *panicTrap
+	r    io.Reader
+}
+
+func (t trappedReader) Read(p []byte) (n int, err error) {
+	defer t.trap.catch(&err)
+	return t.r.Read(p)
+}
 
-	var output, errBuffer bytes.Buffer
-	cmd.Stdout = &output
+// trappedWriter is an io.Writer whose panics are caught by a panicTrap.
+type trappedWriter struct {
+	trap *panicTrap
+	w    io.Writer
+}
+
+func (t trappedWriter) Write(p []byte) (n int, err error) {
+	defer t.trap.catch(&err)
+	return t.w.Write(p)
+}
+
+// dockerExecutable returns the container runtime executable to use.
+func dockerExecutable(executable string) string {
+	if executable != "" {
+		return executable
+	}
+	return DockerDefaultExecutable
+}
+
+// volumeWithLabel returns the given volume in the "source:destination[:options]" syntax, with the "Z" option added if relabel is true.
+// Anonymous volumes, which only consist of a destination, are returned unchanged, as they can't have options.
+func volumeWithLabel(volume string, relabel bool) string {
+	i := strings.LastIndex(volume, ":")
+	if !relabel || i < 0 {
+		return volume
+	}
+
+	// The destination is always an absolute path inside of the container, so anything after it are options.
+	if strings.HasPrefix(volume[i+1:], "/") {
+		return volume + ":Z"
+	}
+	for _, option := range strings.Split(volume[i+1:], ",") {
+		if option == "z" || option == "Z" {
+			return volume
+		}
+	}
+	return volume + ",Z"
+}
+
+// docker runs the container runtime executable with the given arguments, and returns its output.
+// Any error wraps a *DockerError.
+func docker(ctx context.Context, executable string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, dockerExecutable(executable), args...)
+
+	var outBuffer, errBuffer bytes.Buffer
+	cmd.Stdout = &outBuffer
 	cmd.Stderr = &errBuffer
 
 	if err := cmd.Run(); err != nil {
-		switch err := err.(type) {
-		case *exec.ExitError:
-			return nil, ParseStderr(errBuffer.String(), err)
-		default:
-			return nil, err
+		return "", fmt.Errorf("failed to run %s %s: %w", dockerExecutable(executable), args[0], newDockerError(err, errBuffer.String()))
+	}
+
+	return outBuffer.String(), nil
+}
+
+// newWorkspace implements the runner interface.
+//
+// The workspace is bind-mounted into the container.
+func (d Docker) newWorkspace(ctx context.Context, parent string) (*workspace, error) {
+	var ws *workspace
+	var err error
+	if d.CurrentUser {
+		ws, err = newHostWorkspace(parent)
+	} else {
+		// The user inside of the container may differ from the one on the host.
+		ws, err = newSharedHostWorkspace(parent)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ws.typstDir = "/go-typst/workspace-" + randomID()
+	ws.container = true
+	ws.volume = ws.hostDir + ":" + ws.typstDir
+
+	return ws, nil
+}
+
+// dockerCreatedAtLayout is the layout of the CreatedAt field that docker ps outputs.
+const dockerCreatedAtLayout = "2006-01-02 15:04:05 -0700 MST"
+
+// SweepContainers removes orphaned containers that carry the label typst.DockerLabel.
+//
+// Containers can be left behind when the process is killed or crashes during an invocation, or when the daemon restarts.
+// The same applies to the long-running containers of typst.DockerContainer, if Close isn't called.
+// Containers that have exited or are dead are always removed.
+// All other containers, like running or just created ones, may belong to an invocation or a typst.DockerContainer that is still in use.
+// They are only removed if they were created more than maxAge ago, so maxAge should be longer than any invocation may take.
+// A typst.DockerContainer whose container has been removed starts a new one on its next invocation.
+//
+// The IDs of the removed containers are returned.
+func (d Docker) SweepContainers(ctx context.Context, maxAge time.Duration) ([]string, error) {
+	output, err := docker(ctx, d.Executable, "ps", "--all", "--no-trunc", "--filter", "label="+DockerLabel, "--format", "{{.ID}}\t{{.State}}\t{{.CreatedAt}}")
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(-maxAge)
+
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line == "" {
+			continue
 		}
+
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected output of %s ps: %q", dockerExecutable(d.Executable), line)
+		}
+		id, state, createdAt := fields[0], fields[1], fields[2]
+
+		if state != "exited" && state != "dead" {
+			created, err := time.Parse(dockerCreatedAtLayout, createdAt)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse creation time of container %s: %w", id, err)
+			}
+			if created.After(deadline) {
+				continue
+			}
+		}
+
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
 	}
 
-	var result []string
-	scanner := bufio.NewScanner(&output)
-	for scanner.Scan() {
-		result = append(result, scanner.Text())
+	if _, err := docker(ctx, d.Executable, append([]string{"rm", "--force"}, ids...)...); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return ids, nil
+}
+
+// kind implements the runner interface.
+func (d Docker) kind() CallerKind {
+	return CallerKindDocker
+}
+
+// hooks implements the runner interface.
+func (d Docker) hooks() Hooks {
+	return d.Hooks
+}
+
+// VersionString returns the Typst version as a string.
+func (d Docker) VersionString() (string, error) {
+	return d.VersionStringWithContext(context.Background())
+}
+
+// VersionStringWithContext returns the Typst version as a string.
+// The container is killed once ctx is done.
+func (d Docker) VersionStringWithContext(ctx context.Context) (string, error) {
+	return versionString(ctx, d)
+}
+
+// Fonts returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+func (d Docker) Fonts(options *OptionsFonts) ([]string, error) {
+	return d.FontsWithContext(context.Background(), options)
+}
+
+// FontsWithContext returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+// The container is killed once ctx is done.
+func (d Docker) FontsWithContext(ctx context.Context, options *OptionsFonts) ([]string, error) {
+	if !d.TranslatePaths {
+		return fonts(ctx, d, options)
+	}
+
+	t, err := newPathTranslator(d.WorkingDirectory)
+	if err != nil {
+		return nil, err
+	}
+	options = t.fonts(options)
+
+	return fonts(ctx, t.docker(d), options)
 }
 
 // Compile takes a Typst document from input, and renders it into the output writer.
 // The options parameter is optional, and can be nil.
 func (d Docker) Compile(input io.Reader, output io.Writer, options *OptionsCompile) error {
-	args := d.args()
+	return d.CompileWithContext(context.Background(), input, output, options)
+}
+
+// CompileWithContext takes a Typst document from input, and renders it into the output writer.
+// The options parameter is optional, and can be nil.
+// The container is killed once ctx is done.
+func (d Docker) CompileWithContext(ctx context.Context, input io.Reader, output io.Writer, options *OptionsCompile) error {
+	if !d.TranslatePaths {
+		return compile(ctx, d, input, output, options)
+	}
 
-	if options == nil {
-		options = new(OptionsCompile)
+	t, err := newPathTranslator(d.WorkingDirectory)
+	if err != nil {
+		return err
 	}
-	args = append(args, options.Args()...)
+	input, options = t.compile(input, options)
 
-	cmd := exec.Command("docker", args...)
-	cmd.Dir = d.WorkingDirectory
-	cmd.Stdin = input
-	cmd.Stdout = output
+	return t.error(compile(ctx, t.docker(d), input, output, options))
+}
 
-	errBuffer := bytes.Buffer{}
-	cmd.Stderr = &errBuffer
+// Query takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+func (d Docker) Query(input io.Reader, result any, options *OptionsQuery) error {
+	return d.QueryWithContext(context.Background(), input, result, options)
+}
 
-	if err := cmd.Run(); err != nil {
-		switch err := err.(type) {
-		case *exec.ExitError:
-			if err.ExitCode() >= 125 {
-				// Most likely docker related error.
-				// TODO: Find a better way to distinguish between Typst or Docker errors.
-				return fmt.Errorf("exit code %d: %s", err.ExitCode(), errBuffer.String())
-			} else {
-				// Typst related error.
-				return ParseStderr(errBuffer.String(), err)
-			}
-		default:
-			return err
-		}
+// QueryWithContext takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+// The container is killed once ctx is done.
+func (d Docker) QueryWithContext(ctx context.Context, input io.Reader, result any, options *OptionsQuery) error {
+	if !d.TranslatePaths {
+		return query(ctx, d, input, result, options)
 	}
 
-	return nil
+	t, err := newPathTranslator(d.WorkingDirectory)
+	if err != nil {
+		return err
+	}
+	input, options = t.query(input, options)
+
+	return t.error(query(ctx, t.docker(d), input, result, options))
+}
+
+// Watch starts Typst in watch mode, which recompiles the document at the input path whenever it or any of its dependencies change.
+// The result is written to the output path, which may be left empty to let Typst derive it from the input path.
+// The paths are resolved inside of the container, so the watched files have to be mounted via Volumes.
+// The options parameter is optional, and can be nil.
+//
+// The returned Watcher reports all compilations until ctx is done, or until it is closed.
+func (d Docker) Watch(ctx context.Context, input, output string, options *OptionsCompile) (*Watcher, error) {
+	return watch(ctx, d, input, output, options)
 }
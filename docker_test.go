@@ -7,11 +7,14 @@ package typst_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"image"
 	"os"
 	"path/filepath"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/Dadido3/go-typst"
 )
@@ -137,3 +140,21 @@ func TestDocker_CompileWithWorkingDir(t *testing.T) {
 		t.Errorf("No output was written.")
 	}
 }
+
+// Test that a long running compilation is aborted once the context times out.
+func TestDocker_CompileWithContextTimeout(t *testing.T) {
+	typstCaller := typst.Docker{
+		Image: typstDockerImage(),
+	}
+
+	r := bytes.NewBufferString(`#for i in range(1000000000) {}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var w bytes.Buffer
+	err := typstCaller.CompileWithContext(ctx, r, &w, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected error wrapping %v, got %v.", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment configures the environment variables of the Typst process.
+//
+// Native Typst processes inherit the environment of the current process by default, which can be restricted with Clean and Allow.
+// Containers and WebAssembly modules start with an environment of their own instead, and only get the variables of the current process that are listed in Allow.
+// All other variables are passed into containers via a private "--env-file" and into WebAssembly modules directly, so that Typst behaves the same with all callers.
+// Therefore don't allow variables like PATH or HOME for containers, as they would override the ones of the image.
+// The environment of the container runtime itself is never changed.
+type Environment struct {
+	Clean bool     // Don't inherit any variable of the current process, except for the ones in Allow.
+	Allow []string // Names of the variables that are inherited from the current process, like "HOME" or "XDG_CACHE_HOME". If set, all other variables are not inherited.
+
+	SourceDateEpoch  time.Time // The creation date of the documents, set via SOURCE_DATE_EPOCH. Use this for reproducible builds. Ignored if zero.
+	FontPaths        []string  // Additional directories that are searched for fonts, set via TYPST_FONT_PATHS.
+	PackagePath      string    // Directory of local packages, set via TYPST_PACKAGE_PATH.
+	PackageCachePath string    // Directory that downloaded packages are cached in, set via TYPST_PACKAGE_CACHE_PATH.
+	Cert             string    // Path to a CA certificate file that is used to download packages, set via TYPST_CERT.
+	HTTPProxy        string    // Proxy for HTTP requests, set via HTTP_PROXY.
+	HTTPSProxy       string    // Proxy for HTTPS requests, set via HTTPS_PROXY.
+	NoProxy          string    // Comma separated list of hosts that are not proxied, set via NO_PROXY.
+
+	Vars map[string]string // Additional variables. These take precedence over all other variables.
+}
+
+// vars returns all variables that are explicitly set, in the form "NAME=value".
+// Lists of paths are joined with listSeparator.
+func (e *Environment) vars(listSeparator string) []string {
+	var result []string
+	add := func(name, value string) {
+		if value != "" {
+			result = append(result, name+"="+value)
+		}
+	}
+
+	if !e.SourceDateEpoch.IsZero() {
+		add("SOURCE_DATE_EPOCH", strconv.FormatInt(e.SourceDateEpoch.Unix(), 10))
+	}
+	add("TYPST_FONT_PATHS", strings.Join(e.FontPaths, listSeparator))
+	add("TYPST_PACKAGE_PATH", e.PackagePath)
+	add("TYPST_PACKAGE_CACHE_PATH", e.PackageCachePath)
+	add("TYPST_CERT", e.Cert)
+	add("HTTP_PROXY", e.HTTPProxy)
+	add("HTTPS_PROXY", e.HTTPSProxy)
+	add("NO_PROXY", e.NoProxy)
+
+	for _, name := range slices.Sorted(maps.Keys(e.Vars)) {
+		result = append(result, name+"="+e.Vars[name])
+	}
+
+	return result
+}
+
+// inherited returns the variables of the current process that are inherited, in the form "NAME=value".
+func (e *Environment) inherited() []string {
+	if len(e.Allow) == 0 {
+		if e.Clean {
+			return nil
+		}
+		return os.Environ()
+	}
+
+	var result []string
+	for _, name := range e.Allow {
+		if value, ok := os.LookupEnv(name); ok {
+			result = append(result, name+"="+value)
+		}
+	}
+	return result
+}
+
+// environ returns the environment of a native Typst process.
+// A nil environment returns nil, so that the environment of the current process is inherited.
+func (e *Environment) environ() []string {
+	if e == nil {
+		return nil
+	}
+
+	// An empty environment must not be nil, otherwise the environment of the current process is inherited.
+	// Later entries take precedence over earlier ones.
+	environ := []string{}
+	environ = append(environ, e.inherited()...)
+	return append(environ, e.vars(string(filepath.ListSeparator))...)
+}
+
+// containerVars returns the variables of a sandboxed Typst process, like in a container or a WebAssembly module, in the form "NAME=value".
+//
+// Sandboxed processes don't inherit the environment of the current process, only the variables in Allow are passed on.
+// Containers and WebAssembly modules are Unix like, so lists are joined with ":".
+// Every variable is only contained once, later ones take precedence.
+func (e *Environment) containerVars() []string {
+	if e == nil {
+		return nil
+	}
+
+	var all []string
+	for _, name := range e.Allow {
+		if value, ok := os.LookupEnv(name); ok {
+			all = append(all, name+"="+value)
+		}
+	}
+	all = append(all, e.vars(":")...)
+
+	values := make(map[string]string, len(all))
+	for _, v := range all {
+		name, value, _ := strings.Cut(v, "=")
+		values[name] = value
+	}
+
+	var result []string
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		result = append(result, name+"="+values[name])
+	}
+	return result
+}
+
+// envFile writes the variables of a container into a private file, which is passed to the container runtime via "--env-file".
+// This way the values don't affect the container runtime itself, and they don't show up in the list of processes.
+//
+// It returns the arguments for the container runtime, and a function that removes the file again.
+// If there are no variables, no file is written.
+func (e *Environment) envFile() ([]string, func(), error) {
+	vars := e.containerVars()
+	if len(vars) == 0 {
+		return nil, func() {}, nil
+	}
+
+	var content strings.Builder
+	for _, v := range vars {
+		if strings.ContainsAny(v, "\r\n") {
+			name, _, _ := strings.Cut(v, "=")
+			return nil, nil, fmt.Errorf("the environment variable %s contains a line break, which can't be passed into containers", name)
+		}
+		content.WriteString(v + "\n")
+	}
+
+	// Temporary directories are only accessible by the current user.
+	dir, err := os.MkdirTemp("", "go-typst-env-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	remove := func() { os.RemoveAll(dir) } //nolint:errcheck
+
+	path := filepath.Join(dir, "env")
+	if err := os.WriteFile(path, []byte(content.String()), 0600); err != nil {
+		remove()
+		return nil, nil, fmt.Errorf("failed to write environment file: %w", err)
+	}
+
+	return []string{"--env-file", path}, remove, nil
+}
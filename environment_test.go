@@ -0,0 +1,131 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+//go:build unix
+
+package typst_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dadido3/go-typst"
+)
+
+// testEnvironment returns an environment that sets all typed variables, and allows a single variable of the current process.
+func testEnvironment(t *testing.T) *typst.Environment {
+	t.Setenv("GO_TYPST_ALLOWED", "allowed")
+	t.Setenv("GO_TYPST_SECRET", "secret")
+
+	return &typst.Environment{
+		Clean:            true,
+		Allow:            []string{"GO_TYPST_ALLOWED"},
+		SourceDateEpoch:  time.Unix(1700000000, 0),
+		FontPaths:        []string{"/fonts", "/more-fonts"},
+		PackagePath:      "/packages",
+		PackageCachePath: "/cache",
+		Cert:             "/cert.pem",
+		HTTPSProxy:       "http://proxy:3128",
+		Vars:             map[string]string{"TYPST_FEATURES": "html"},
+	}
+}
+
+func TestCLI_Environment(t *testing.T) {
+	fakeRuntime(t, "typst-env", `env`)
+
+	typstCaller := typst.CLI{ExecutablePath: "typst-env", Environment: testEnvironment(t)}
+
+	output, err := typstCaller.VersionString()
+	if err != nil {
+		t.Fatalf("Failed to run Typst: %v.", err)
+	}
+
+	for _, want := range []string{
+		"GO_TYPST_ALLOWED=allowed",
+		"SOURCE_DATE_EPOCH=1700000000",
+		"TYPST_FONT_PATHS=/fonts:/more-fonts",
+		"TYPST_PACKAGE_PATH=/packages",
+		"TYPST_PACKAGE_CACHE_PATH=/cache",
+		"TYPST_CERT=/cert.pem",
+		"HTTPS_PROXY=http://proxy:3128",
+		"TYPST_FEATURES=html",
+	} {
+		if !strings.Contains(output, want+"\n") {
+			t.Errorf("Environment %q doesn't contain %q.", output, want)
+		}
+	}
+	if strings.Contains(output, "GO_TYPST_SECRET") {
+		t.Errorf("Environment %q contains variable that is not allowed.", output)
+	}
+}
+
+func TestDocker_Environment(t *testing.T) {
+	// Outputs the environment file, and whether any variable has leaked into the environment of the container runtime.
+	logPath := fakeRuntime(t, "docker-env", `while [ $# -gt 0 ]; do [ "$1" != --env-file ] || cat "$2"; shift; done
+[ -z "$TYPST_FONT_PATHS" ] && [ "$DOCKER_HOST" != tcp://typst:2375 ] || echo leaked`)
+
+	env := testEnvironment(t)
+	env.Vars["DOCKER_HOST"] = "tcp://typst:2375"
+	typstCaller := typst.Docker{Executable: "docker-env", Environment: env}
+
+	output, err := typstCaller.VersionString()
+	if err != nil {
+		t.Fatalf("Failed to run Typst: %v.", err)
+	}
+
+	for _, want := range []string{
+		"GO_TYPST_ALLOWED=allowed",
+		"SOURCE_DATE_EPOCH=1700000000",
+		"TYPST_FONT_PATHS=/fonts:/more-fonts",
+		"HTTPS_PROXY=http://proxy:3128",
+		"TYPST_FEATURES=html",
+		"DOCKER_HOST=tcp://typst:2375",
+	} {
+		if !strings.Contains(output, want+"\n") {
+			t.Errorf("Environment file %q doesn't contain %q.", output, want)
+		}
+	}
+	for _, unwanted := range []string{"GO_TYPST_SECRET", "HOME=", "leaked"} {
+		if strings.Contains(output, unwanted) {
+			t.Errorf("Output %q contains %q.", output, unwanted)
+		}
+	}
+
+	calls := readCalls(t, logPath)
+	if len(calls) != 1 {
+		t.Fatalf("Unexpected number of invocations. Got %d, want %d.", len(calls), 1)
+	}
+	_, rest, ok := strings.Cut(calls[0], " --env-file ")
+	if !ok {
+		t.Fatalf("Invocation %q doesn't contain %q.", calls[0], "--env-file")
+	}
+	for _, unwanted := range []string{"proxy:3128", " -e "} {
+		if strings.Contains(calls[0], unwanted) {
+			t.Errorf("Invocation %q contains %q.", calls[0], unwanted)
+		}
+	}
+
+	// The environment file must be removed after the invocation.
+	envPath, _, _ := strings.Cut(rest, " ")
+	if _, err := os.Stat(envPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected environment file %q to be removed, got %v.", envPath, err)
+	}
+}
+
+func TestDocker_EnvironmentLineBreak(t *testing.T) {
+	logPath := fakeRuntime(t, "docker-env", ``)
+
+	typstCaller := typst.Docker{Executable: "docker-env", Environment: &typst.Environment{Vars: map[string]string{"FOO": "a\nb"}}}
+
+	if _, err := typstCaller.VersionString(); err == nil {
+		t.Fatalf("Expected error, but got nil.")
+	}
+	if _, err := os.Stat(logPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the container runtime not to be invoked.")
+	}
+}
@@ -11,12 +11,25 @@ import (
 	"strings"
 )
 
+// DiagnosticSeverity is the severity of a diagnostic that Typst has emitted.
+type DiagnosticSeverity string
+
+const (
+	DiagnosticSeverityError   DiagnosticSeverity = "error"
+	DiagnosticSeverityWarning DiagnosticSeverity = "warning"
+	DiagnosticSeverityHelp    DiagnosticSeverity = "help" // Used for trace entries that point to the origin of another diagnostic, like "error occurred in this call".
+	DiagnosticSeverityHint    DiagnosticSeverity = "hint"
+)
+
 // ErrorDetails contains the details of a typst.Error.
 type ErrorDetails struct {
-	Message string // The parsed error message.
-	Path    string // Path of the Typst file where the error is located in. Zero value means that there is no further information.
-	Line    int    // Line number of the error. Zero value means that there is no further information.
-	Column  int    // Column of the error. Zero value means that there is no further information.
+	Message   string             // The parsed error message.
+	Severity  DiagnosticSeverity // The severity of the diagnostic. Zero value means that there is no further information.
+	Path      string             // Path of the Typst file where the error is located in. Zero value means that there is no further information.
+	Line      int                // Line number of the error. Zero value means that there is no further information.
+	Column    int                // Column of the error. Zero value means that there is no further information.
+	EndLine   int                // Line number where the erroneous span ends. Zero value means that there is no further information. Only available with the human diagnostic format.
+	EndColumn int                // Column where the erroneous span ends (inclusive). Zero value means that there is no further information. Only available with the human diagnostic format.
 }
 
 // Error represents an error as returned by Typst.
@@ -40,13 +53,39 @@ func (e *Error) Unwrap() error {
 
 var stderrRegex = regexp.MustCompile(`(?s)^(?<error>.+?)(?:(?:\n\s+┌─ (?<path>.+?):(?<line>\d+):(?<column>\d+)\n)|(?:$))`)
 
+// stderrShortRegex matches a single diagnostic in the short diagnostic format.
+// The path is matched lazily, as paths can contain colons, too.
+var stderrShortRegex = regexp.MustCompile(`^(?:(?<path>.+?):(?<line>\d+):(?<column>\d+): )?(?<severity>error|warning|help|hint)(?:\[[^\]]*\])?: (?<message>.*)$`)
+
+// stderrHumanIndicatorRegex matches lines that only appear in the human diagnostic format.
+var stderrHumanIndicatorRegex = regexp.MustCompile(`(?m)^\s*(?:┌─ |= )`)
+
+// stderrSeverityRegex matches the severity at the beginning of a diagnostic message.
+var stderrSeverityRegex = regexp.MustCompile(`^(?<severity>error|warning|help|hint)\b`)
+
+// stderrSourceLineRegex matches source excerpts in the human diagnostic format.
+var stderrSourceLineRegex = regexp.MustCompile(`^\s*(?<line>\d+) │ `)
+
 // ParseStderr will parse the given stderr output and return a typst.Error.
+//
+// The human and the short diagnostic format are both supported, the used format is detected automatically.
 func ParseStderr(stderr string, inner error) error {
 	err := Error{
 		Inner: inner,
 		Raw:   stderr,
 	}
 
+	if stderrHumanIndicatorRegex.MatchString(stderr) {
+		err.Details = parseStderrHuman(stderr)
+	} else {
+		err.Details = parseStderrShort(stderr)
+	}
+
+	return &err
+}
+
+// parseStderrHuman parses stderr output in the human diagnostic format.
+func parseStderrHuman(stderr string) (result []ErrorDetails) {
 	// Get all "blocks" ending with double new lines.
 	parts := strings.Split(stderr, "\n\n")
 	parts = parts[:len(parts)-1]
@@ -71,10 +110,90 @@ func ParseStderr(stderr string, inner error) error {
 					details.Column = int(column)
 				}
 			}
+			if severity := stderrSeverityRegex.FindString(details.Message); severity != "" {
+				details.Severity = DiagnosticSeverity(severity)
+			}
+			if details.Line > 0 {
+				details.EndLine, details.EndColumn = parseStderrHumanSpanEnd(part, details.Line, details.Column)
+			}
 
-			err.Details = append(err.Details, details)
+			result = append(result, details)
 		}
 	}
 
-	return &err
+	return
+}
+
+// parseStderrHumanSpanEnd determines the end of the first labeled span from the source excerpt of a diagnostic in the human format.
+// It returns zero values if the end can't be determined.
+func parseStderrHumanSpanEnd(block string, line, column int) (endLine, endColumn int) {
+	lastSourceLine := 0
+	for _, text := range strings.Split(block, "\n") {
+		if match := stderrSourceLineRegex.FindStringSubmatch(text); match != nil {
+			if number, err := strconv.Atoi(match[stderrSourceLineRegex.SubexpIndex("line")]); err == nil {
+				lastSourceLine = number
+			}
+			continue
+		}
+
+		// Caret lines are the only lines that contain carets below the gutter.
+		_, marks, ok := strings.Cut(text, "│ ")
+		if !ok || !strings.Contains(marks, "^") || lastSourceLine == 0 {
+			continue
+		}
+
+		switch {
+		case strings.Contains(marks, "╰"):
+			// End of a multi-line span, the column can't be determined reliably.
+			return lastSourceLine, 0
+		case strings.Contains(marks, "╭"):
+			// Start of a multi-line span, the end follows later.
+		default:
+			// Single-line span, the number of carets is its length.
+			carets := strings.Count(marks, "^")
+			if column == 0 || lastSourceLine != line || carets == 0 {
+				return lastSourceLine, 0
+			}
+			return line, column + carets - 1
+		}
+	}
+
+	return 0, 0
+}
+
+// parseStderrShort parses stderr output in the short diagnostic format.
+//
+// Lines that don't start a new diagnostic are appended to the message of the previous diagnostic, until an empty line is encountered.
+func parseStderrShort(stderr string) (result []ErrorDetails) {
+	continuation := false
+	for _, text := range strings.Split(stderr, "\n") {
+		parsed := stderrShortRegex.FindStringSubmatch(text)
+		if parsed == nil {
+			switch {
+			case text == "":
+				continuation = false
+			case continuation && len(result) > 0:
+				result[len(result)-1].Message += "\n" + text
+			}
+			continue
+		}
+
+		var details ErrorDetails
+
+		severity := parsed[stderrShortRegex.SubexpIndex("severity")]
+		details.Severity = DiagnosticSeverity(severity)
+		details.Message = severity + ": " + parsed[stderrShortRegex.SubexpIndex("message")]
+		details.Path = parsed[stderrShortRegex.SubexpIndex("path")]
+		if line, err := strconv.ParseInt(parsed[stderrShortRegex.SubexpIndex("line")], 10, 0); err == nil {
+			details.Line = int(line)
+		}
+		if column, err := strconv.ParseInt(parsed[stderrShortRegex.SubexpIndex("column")], 10, 0); err == nil {
+			details.Column = int(column)
+		}
+
+		result = append(result, details)
+		continuation = true
+	}
+
+	return
 }
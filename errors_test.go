@@ -90,6 +90,88 @@ func TestErrors2(t *testing.T) {
 	}
 }
 
+func TestErrors3(t *testing.T) {
+	cli := typst.CLI{}
+
+	opts := typst.OptionsCompile{
+		DiagnosticFormat: typst.DiagnosticFormatHuman,
+	}
+
+	r := bytes.NewBufferString(`This is a test!
+
+#assert(1 < 1, message: "Test")`)
+
+	var w bytes.Buffer
+	if err := cli.Compile(r, &w, &opts); err == nil {
+		t.Fatalf("Expected error, but got nil")
+	} else {
+		var errTypst *typst.Error
+		if errors.As(err, &errTypst) {
+			if len(errTypst.Details) != 1 {
+				t.Fatalf("Expected error doesn't contain the expected number of detail entries. Got %v, want %v", len(errTypst.Details), 1)
+			}
+			details := errTypst.Details[0]
+			if details.Severity != typst.DiagnosticSeverityError {
+				t.Errorf("Expected error with severity %q, got %q", typst.DiagnosticSeverityError, details.Severity)
+			}
+			if details.Line != 3 || details.EndLine != 3 {
+				t.Errorf("Expected error to span line %d, got lines %d to %d", 3, details.Line, details.EndLine)
+			}
+			if details.EndColumn <= details.Column {
+				t.Errorf("Expected error to end after column %d, got column %d", details.Column, details.EndColumn)
+			}
+		} else {
+			t.Errorf("Expected error type %T, got %T: %v", errTypst, err, err)
+		}
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	cli := typst.CLI{}
+
+	var warnings []typst.ErrorDetails
+	opts := typst.OptionsCompile{
+		WarningHandler: func(w []typst.ErrorDetails) { warnings = append(warnings, w...) },
+	}
+
+	r := bytes.NewBufferString(`#set text(font: "This font doesn't exist")
+This is a test!`)
+
+	var w bytes.Buffer
+	if err := cli.Compile(r, &w, &opts); err != nil {
+		t.Fatalf("Failed to compile document: %v", err)
+	}
+
+	if len(warnings) == 0 {
+		t.Fatalf("Expected warnings, but got none")
+	}
+	if warnings[0].Severity != typst.DiagnosticSeverityWarning {
+		t.Errorf("Expected diagnostic with severity %q, got %q", typst.DiagnosticSeverityWarning, warnings[0].Severity)
+	}
+	if warnings[0].Line != 1 {
+		t.Errorf("Expected warning to point at line %d, got line %d", 1, warnings[0].Line)
+	}
+}
+
+func TestWarningsNone(t *testing.T) {
+	cli := typst.CLI{}
+
+	called := false
+	opts := typst.OptionsCompile{
+		WarningHandler: func(w []typst.ErrorDetails) { called = true },
+	}
+
+	r := bytes.NewBufferString(`This is a test!`)
+
+	var w bytes.Buffer
+	if err := cli.Compile(r, &w, &opts); err != nil {
+		t.Fatalf("Failed to compile document: %v", err)
+	}
+	if called {
+		t.Errorf("Warning handler was called without any warnings")
+	}
+}
+
 func TestErrorParsing(t *testing.T) {
 	var tests = map[string]struct {
 		StdErr          string               // The original and raw stderr message.
@@ -99,13 +181,17 @@ func TestErrorParsing(t *testing.T) {
 			StdErr: "warning: html export is under active development and incomplete\n = hint: its behaviour may change at any time\n = hint: do not rely on this feature for production use cases\n = hint: see https://github.com/typst/typst/issues/5512 for more information\n\nerror: page configuration is not allowed inside of containers\n  ┌─ \\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\<stdin>:1:1\n  │\n1 │ #set page(width: 100mm, height: auto, margin: 5mm)\n  │  ^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^\n\n",
 			ExpectedDetails: []typst.ErrorDetails{
 				{
-					Message: "warning: html export is under active development and incomplete\n = hint: its behaviour may change at any time\n = hint: do not rely on this feature for production use cases\n = hint: see https://github.com/typst/typst/issues/5512 for more information",
+					Message:  "warning: html export is under active development and incomplete\n = hint: its behaviour may change at any time\n = hint: do not rely on this feature for production use cases\n = hint: see https://github.com/typst/typst/issues/5512 for more information",
+					Severity: "warning",
 				},
 				{
-					Message: "error: page configuration is not allowed inside of containers",
-					Path:    "\\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\<stdin>",
-					Line:    1,
-					Column:  1,
+					Message:   "error: page configuration is not allowed inside of containers",
+					Severity:  "error",
+					Path:      "\\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\<stdin>",
+					Line:      1,
+					Column:    1,
+					EndLine:   1,
+					EndColumn: 49,
 				},
 			},
 		},
@@ -113,10 +199,13 @@ func TestErrorParsing(t *testing.T) {
 			StdErr: "error: expected expression\n   ┌─ \\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\<stdin>:12:34\n   │\n12 │ - Test coverage of most features.#\n   │                                   ^\n\n",
 			ExpectedDetails: []typst.ErrorDetails{
 				{
-					Message: "error: expected expression",
-					Path:    "\\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\<stdin>",
-					Line:    12,
-					Column:  34,
+					Message:   "error: expected expression",
+					Severity:  "error",
+					Path:      "\\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\<stdin>",
+					Line:      12,
+					Column:    34,
+					EndLine:   12,
+					EndColumn: 34,
 				},
 			},
 		},
@@ -124,16 +213,22 @@ func TestErrorParsing(t *testing.T) {
 			StdErr: "error: expected expression\n   ┌─ \\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\<stdin>:11:53\n   │\n11 │ - Uses stdio; No temporary files need to be created.#\n   │                                                      ^\n\nerror: expected expression\n   ┌─ \\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\<stdin>:12:34\n   │\n12 │ - Test coverage of most features.#\n   │                                   ^\n\n",
 			ExpectedDetails: []typst.ErrorDetails{
 				{
-					Message: "error: expected expression",
-					Path:    "\\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\<stdin>",
-					Line:    11,
-					Column:  53,
+					Message:   "error: expected expression",
+					Severity:  "error",
+					Path:      "\\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\<stdin>",
+					Line:      11,
+					Column:    53,
+					EndLine:   11,
+					EndColumn: 53,
 				},
 				{
-					Message: "error: expected expression",
-					Path:    "\\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\<stdin>",
-					Line:    12,
-					Column:  34,
+					Message:   "error: expected expression",
+					Severity:  "error",
+					Path:      "\\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\<stdin>",
+					Line:      12,
+					Column:    34,
+					EndLine:   12,
+					EndColumn: 34,
 				},
 			},
 		},
@@ -141,16 +236,22 @@ func TestErrorParsing(t *testing.T) {
 			StdErr: "error: expected expression\n  ┌─ \\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\test.typ:1:4\n  │\n1 │ hey#\n  │     ^\n\nhelp: error occurred while importing this module\n   ┌─ \\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\<stdin>:14:9\n   │\n14 │ #include \"test.typ\"\n   │          ^^^^^^^^^^\n\n",
 			ExpectedDetails: []typst.ErrorDetails{
 				{
-					Message: "error: expected expression",
-					Path:    "\\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\test.typ",
-					Line:    1,
-					Column:  4,
+					Message:   "error: expected expression",
+					Severity:  "error",
+					Path:      "\\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\test.typ",
+					Line:      1,
+					Column:    4,
+					EndLine:   1,
+					EndColumn: 4,
 				},
 				{
-					Message: "help: error occurred while importing this module",
-					Path:    "\\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\<stdin>",
-					Line:    14,
-					Column:  9,
+					Message:   "help: error occurred while importing this module",
+					Severity:  "help",
+					Path:      "\\\\?\\C:\\Users\\David Vogel\\Desktop\\Synced\\Go\\Libraries\\go-typst\\<stdin>",
+					Line:      14,
+					Column:    9,
+					EndLine:   14,
+					EndColumn: 18,
 				},
 			},
 		},
@@ -158,7 +259,65 @@ func TestErrorParsing(t *testing.T) {
 			StdErr: "error: invalid value 'a' for '--pages <PAGES>': not a valid page number\n\nFor more information, try '--help'.\n",
 			ExpectedDetails: []typst.ErrorDetails{
 				{
-					Message: "error: invalid value 'a' for '--pages <PAGES>': not a valid page number",
+					Message:  "error: invalid value 'a' for '--pages <PAGES>': not a valid page number",
+					Severity: "error",
+				},
+			},
+		},
+		"Typst 0.13.0 short warning + error": {
+			StdErr: "warning: html export is under active development and incomplete\n\\\\?\\C:\\Users\\David Vogel\\go-typst\\<stdin>:1:1: error: page configuration is not allowed inside of containers\n",
+			ExpectedDetails: []typst.ErrorDetails{
+				{
+					Message:  "warning: html export is under active development and incomplete",
+					Severity: "warning",
+				},
+				{
+					Message:  "error: page configuration is not allowed inside of containers",
+					Severity: "error",
+					Path:     "\\\\?\\C:\\Users\\David Vogel\\go-typst\\<stdin>",
+					Line:     1,
+					Column:   1,
+				},
+			},
+		},
+		"Typst 0.13.0 short stacked errors with paths": {
+			StdErr: "/home/user/a:b/test.typ:1:4: error: expected expression\n/home/user/a:b/main.typ:14:9: help: error occurred while importing this module\n",
+			ExpectedDetails: []typst.ErrorDetails{
+				{
+					Message:  "error: expected expression",
+					Severity: "error",
+					Path:     "/home/user/a:b/test.typ",
+					Line:     1,
+					Column:   4,
+				},
+				{
+					Message:  "help: error occurred while importing this module",
+					Severity: "help",
+					Path:     "/home/user/a:b/main.typ",
+					Line:     14,
+					Column:   9,
+				},
+			},
+		},
+		"Typst 0.13.0 short error without path": {
+			StdErr: "error: invalid value 'a' for '--pages <PAGES>': not a valid page number\n\nFor more information, try '--help'.\n",
+			ExpectedDetails: []typst.ErrorDetails{
+				{
+					Message:  "error: invalid value 'a' for '--pages <PAGES>': not a valid page number",
+					Severity: "error",
+				},
+			},
+		},
+		"Typst 0.13.0 multi-line span": {
+			StdErr: "error: unclosed delimiter\n  ┌─ main.typ:1:5\n  │\n1 │   foo(\n  │ ╭─────^\n2 │ │   bar\n3 │ │ )\n  │ ╰─^\n\n",
+			ExpectedDetails: []typst.ErrorDetails{
+				{
+					Message:  "error: unclosed delimiter",
+					Severity: "error",
+					Path:     "main.typ",
+					Line:     1,
+					Column:   5,
+					EndLine:  3,
 				},
 			},
 		},
@@ -0,0 +1,381 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBackendUnavailable is reported for a backend of a FallbackCaller that is skipped because of repeated failures.
+var ErrBackendUnavailable = errors.New("the backend is skipped because of repeated failures")
+
+// FallbackAction is the reaction of a FallbackCaller to an error of one of its backends.
+type FallbackAction int
+
+const (
+	FallbackActionFail     FallbackAction = iota // The error is returned as is, no other backend is tried.
+	FallbackActionFallback                       // The next backend is tried.
+	FallbackActionRetry                          // The same backend is tried again after a backoff, and the next backend once all retries are used up.
+)
+
+// ClassifyError is the default classification of errors for a FallbackCaller.
+//
+// Errors in the document, like a *typst.Error or a *typst.ValidationError, are never retried, as every backend would fail the same way.
+// Only a *typst.Error whose exit code isn't 1, like the one of a panic or a signal, is treated as a crash of Typst and causes a fallback.
+// Missing or broken executables, any *typst.DockerError and a full or closed PoolCaller are infrastructure errors that cause a fallback.
+// Docker errors that are likely transient, like an unreachable daemon or a failed pull, are retried first.
+func ClassifyError(err error) FallbackAction {
+	var typstErr *Error
+	if errors.As(err, &typstErr) {
+		// Typst exits with code 1 on errors in the document.
+		// Any other exit code means that it has crashed, or has been killed.
+		var exitErr exitError
+		if errors.As(typstErr, &exitErr) && exitErr.ExitCode() != 1 {
+			return FallbackActionFallback
+		}
+		return FallbackActionFail
+	}
+
+	var dockerErr *DockerError
+	if errors.As(err, &dockerErr) {
+		switch dockerErr.Kind {
+		case DockerErrorDaemonUnreachable, DockerErrorPullFailed, DockerErrorContainerNotRunning:
+			return FallbackActionRetry
+		}
+		return FallbackActionFallback
+	}
+
+	var poolFullErr *PoolFullError
+	if errors.As(err, &poolFullErr) {
+		return FallbackActionRetry
+	}
+
+	var execErr *exec.Error
+	if errors.As(err, &execErr) || errors.Is(err, ErrPoolClosed) || errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+		return FallbackActionFallback
+	}
+
+	return FallbackActionFail
+}
+
+// FallbackAttempt is a failed attempt of a FallbackCaller.
+type FallbackAttempt struct {
+	Index int   // The index of the backend in FallbackCaller.Callers.
+	Err   error // The error of the backend, or typst.ErrBackendUnavailable if it was skipped.
+}
+
+// FallbackError is returned by a FallbackCaller if none of its backends could serve a call.
+//
+// It unwraps into the errors of all attempts, so errors.As can be used to look for a specific error like *typst.DockerError.
+type FallbackError struct {
+	Attempts []FallbackAttempt
+}
+
+func (e *FallbackError) Error() string {
+	if len(e.Attempts) == 0 {
+		return "no backend available"
+	}
+
+	messages := make([]string, 0, len(e.Attempts))
+	for _, attempt := range e.Attempts {
+		messages = append(messages, fmt.Sprintf("backend %d: %v", attempt.Index, attempt.Err))
+	}
+	return "all backends have failed: " + strings.Join(messages, "; ")
+}
+
+func (e *FallbackError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Attempts))
+	for _, attempt := range e.Attempts {
+		errs = append(errs, attempt.Err)
+	}
+	return errs
+}
+
+// FallbackReport describes which backend of a FallbackCaller has served a call.
+// See typst.WithFallbackReport.
+type FallbackReport struct {
+	Index    int               // The index of the backend in FallbackCaller.Callers that has served the call, or -1 if none did.
+	Caller   Caller            // The backend that has served the call, or nil if none did.
+	Attempts []FallbackAttempt // All failed attempts before the call was served, including retries.
+}
+
+// fallbackReportContextKey is the context key for a *FallbackReport.
+type fallbackReportContextKey struct{}
+
+// WithFallbackReport returns a copy of ctx that makes FallbackCaller fill report once the call has finished.
+//
+//	var report typst.FallbackReport
+//	err := fallbackCaller.CompileWithContext(typst.WithFallbackReport(ctx, &report), input, output, options)
+//	log.Printf("Compiled by backend %d", report.Index)
+func WithFallbackReport(ctx context.Context, report *FallbackReport) context.Context {
+	return context.WithValue(ctx, fallbackReportContextKey{}, report)
+}
+
+// FallbackCaller wraps several Callers, and tries them in order until one of them succeeds.
+//
+// How an error of a backend is handled is decided by Classify:
+// Errors in the document are returned directly, infrastructure errors cause the next backend to be tried, and transient errors are retried with an exponential backoff first.
+//
+// Each backend has a circuit breaker:
+// After FailureThreshold consecutive failed calls, the backend is skipped for the duration of Cooldown.
+// After that it is tried again, and every further failure skips it again until it succeeds once.
+//
+// Input documents and outputs are buffered in memory, so that they can be passed to another backend.
+// This isn't necessary for typst.InputFile and typst.OutputFile, but all backends have to be able to access these files.
+//
+// Create it with &typst.FallbackCaller{Callers: []typst.Caller{typst.CLI{}, typst.Docker{}}}, and don't copy or modify it after first use.
+// It is safe for concurrent use.
+type FallbackCaller struct {
+	Callers []Caller // The backends in the order they are tried.
+
+	// Decides how errors of backends are handled.
+	// Defaults to typst.ClassifyError.
+	Classify func(err error) FallbackAction
+
+	Retries    int           // The number of retries per backend for errors that are classified as typst.FallbackActionRetry.
+	Backoff    time.Duration // The delay before the first retry, which doubles with every further retry. Defaults to 100 ms.
+	MaxBackoff time.Duration // The upper limit of the delay between retries. Defaults to 5 s.
+
+	FailureThreshold int           // The number of consecutive failed calls after which a backend is skipped. If 0, backends are never skipped.
+	Cooldown         time.Duration // How long a backend is skipped. Defaults to 30 s.
+
+	mutex    sync.Mutex
+	breakers []fallbackBreaker
+}
+
+// fallbackBreaker is the circuit breaker state of a single backend.
+type fallbackBreaker struct {
+	failures  int       // Number of consecutive failed calls.
+	openUntil time.Time // The backend is skipped until this time.
+}
+
+// Ensure that FallbackCaller implements the ContextCaller and QueryCaller interfaces.
+var (
+	_ ContextCaller = &FallbackCaller{}
+	_ QueryCaller   = &FallbackCaller{}
+)
+
+// available returns whether the backend with the given index may be tried.
+func (c *FallbackCaller) available(index int) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.breakers == nil {
+		c.breakers = make([]fallbackBreaker, len(c.Callers))
+	}
+
+	return !time.Now().Before(c.breakers[index].openUntil)
+}
+
+// record updates the circuit breaker of the backend with the given index.
+func (c *FallbackCaller) record(index int, success bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	breaker := &c.breakers[index]
+	if success {
+		breaker.failures = 0
+		return
+	}
+
+	breaker.failures++
+	if c.FailureThreshold > 0 && breaker.failures >= c.FailureThreshold {
+		cooldown := c.Cooldown
+		if cooldown <= 0 {
+			cooldown = 30 * time.Second
+		}
+		breaker.openUntil = time.Now().Add(cooldown)
+	}
+}
+
+// backoff returns the delay before the given retry, starting at 0.
+func (c *FallbackCaller) backoff(retry int) time.Duration {
+	delay, maxDelay := c.Backoff, c.MaxBackoff
+	if delay <= 0 {
+		delay = 100 * time.Millisecond
+	}
+	if maxDelay <= 0 {
+		maxDelay = 5 * time.Second
+	}
+
+	for range retry {
+		if delay >= maxDelay {
+			break
+		}
+		delay *= 2
+	}
+
+	return min(delay, maxDelay)
+}
+
+// do calls f with the backends in order, until one of them succeeds or the error must not be retried.
+func (c *FallbackCaller) do(ctx context.Context, f func(caller Caller) error) error {
+	classify := c.Classify
+	if classify == nil {
+		classify = ClassifyError
+	}
+
+	report := FallbackReport{Index: -1}
+	defer func() {
+		if r, ok := ctx.Value(fallbackReportContextKey{}).(*FallbackReport); ok {
+			*r = report
+		}
+	}()
+
+	for i, caller := range c.Callers {
+		if !c.available(i) {
+			report.Attempts = append(report.Attempts, FallbackAttempt{Index: i, Err: ErrBackendUnavailable})
+			continue
+		}
+
+		for retry := 0; ; retry++ {
+			err := f(caller)
+			if err == nil {
+				c.record(i, true)
+				report.Index, report.Caller = i, caller
+				return nil
+			}
+			if ctx.Err() != nil {
+				return err
+			}
+
+			action := classify(err)
+			if action == FallbackActionFail {
+				// The backend has worked, the error is genuine.
+				c.record(i, true)
+				report.Index, report.Caller = i, caller
+				return err
+			}
+
+			report.Attempts = append(report.Attempts, FallbackAttempt{Index: i, Err: err})
+			if action != FallbackActionRetry || retry >= c.Retries {
+				break
+			}
+
+			select {
+			case <-time.After(c.backoff(retry)):
+			case <-ctx.Done():
+				return fmt.Errorf("typst invocation was aborted: %w", ctx.Err())
+			}
+		}
+
+		c.record(i, false)
+	}
+
+	return &FallbackError{Attempts: report.Attempts}
+}
+
+// VersionString returns the Typst version as a string.
+func (c *FallbackCaller) VersionString() (string, error) {
+	return c.VersionStringWithContext(context.Background())
+}
+
+// VersionStringWithContext returns the Typst version as a string.
+func (c *FallbackCaller) VersionStringWithContext(ctx context.Context) (string, error) {
+	var result string
+	err := c.do(ctx, func(caller Caller) (err error) {
+		result, err = versionStringWithContext(ctx, caller)
+		return err
+	})
+	return result, err
+}
+
+// Fonts returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+func (c *FallbackCaller) Fonts(options *OptionsFonts) ([]string, error) {
+	return c.FontsWithContext(context.Background(), options)
+}
+
+// FontsWithContext returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+func (c *FallbackCaller) FontsWithContext(ctx context.Context, options *OptionsFonts) ([]string, error) {
+	var result []string
+	err := c.do(ctx, func(caller Caller) (err error) {
+		result, err = fontsWithContext(ctx, caller, options)
+		return err
+	})
+	return result, err
+}
+
+// bufferedInput reads input into memory, so that it can be passed to several backends.
+// It returns a function that returns a fresh reader for every backend.
+func bufferedInput(input io.Reader) (func() io.Reader, error) {
+	if file, ok := input.(InputFile); ok {
+		return func() io.Reader { return file }, nil
+	}
+
+	content, err := io.ReadAll(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	return func() io.Reader { return bytes.NewReader(content) }, nil
+}
+
+// Compile takes a Typst document from input, and renders it into the output writer.
+// The options parameter is optional, and can be nil.
+func (c *FallbackCaller) Compile(input io.Reader, output io.Writer, options *OptionsCompile) error {
+	return c.CompileWithContext(context.Background(), input, output, options)
+}
+
+// CompileWithContext takes a Typst document from input, and renders it into the output writer.
+// The options parameter is optional, and can be nil.
+func (c *FallbackCaller) CompileWithContext(ctx context.Context, input io.Reader, output io.Writer, options *OptionsCompile) error {
+	newInput, err := bufferedInput(input)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := output.(OutputFile); ok {
+		return c.do(ctx, func(caller Caller) error {
+			return compileWithContext(ctx, caller, newInput(), output, options)
+		})
+	}
+
+	// Only write the output of the backend that has succeeded.
+	var buffer bytes.Buffer
+	err = c.do(ctx, func(caller Caller) error {
+		buffer.Reset()
+		return compileWithContext(ctx, caller, newInput(), &buffer, options)
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := buffer.WriteTo(output); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// Query takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+func (c *FallbackCaller) Query(input io.Reader, result any, options *OptionsQuery) error {
+	return c.QueryWithContext(context.Background(), input, result, options)
+}
+
+// QueryWithContext takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+func (c *FallbackCaller) QueryWithContext(ctx context.Context, input io.Reader, result any, options *OptionsQuery) error {
+	newInput, err := bufferedInput(input)
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, func(caller Caller) error {
+		return queryWithContext(ctx, caller, newInput(), result, options)
+	})
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Dadido3/go-typst"
+)
+
+// flakyCaller is a fakeCaller whose first compilations fail with err.
+type flakyCaller struct {
+	*fakeCaller
+	failures atomic.Int32 // Number of compilations that will still fail.
+	err      error
+}
+
+func (f *flakyCaller) CompileWithContext(ctx context.Context, input io.Reader, output io.Writer, options *typst.OptionsCompile) error {
+	if f.failures.Add(-1) >= 0 {
+		f.compilations.Add(1)
+		return f.err
+	}
+	return f.fakeCaller.CompileWithContext(ctx, input, output, options)
+}
+
+// exitCodeError is an error of a process that has exited with the given code.
+type exitCodeError int
+
+func (e exitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+func (e exitCodeError) ExitCode() int {
+	return int(e)
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want typst.FallbackAction
+	}{
+		{"Typst error", &typst.Error{Details: []typst.ErrorDetails{{Message: "unknown variable: foo"}}}, typst.FallbackActionFail},
+		{"Typst error without details", &typst.Error{Inner: exitCodeError(1), Raw: "unparsable output"}, typst.FallbackActionFail},
+		{"Typst crash", &typst.Error{Inner: exitCodeError(101), Raw: "thread 'main' panicked"}, typst.FallbackActionFallback},
+		{"Typst killed", &typst.Error{Inner: exitCodeError(-1)}, typst.FallbackActionFallback},
+		{"Validation error", &typst.ValidationError{Field: "PPI", Message: "must not be negative"}, typst.FallbackActionFail},
+		{"Executable not found", &exec.Error{Name: "typst", Err: exec.ErrNotFound}, typst.FallbackActionFallback},
+		{"Docker not found", &typst.DockerError{Kind: typst.DockerErrorExecutableNotFound}, typst.FallbackActionFallback},
+		{"Docker daemon", &typst.DockerError{Kind: typst.DockerErrorDaemonUnreachable}, typst.FallbackActionRetry},
+		{"Docker unknown", &typst.DockerError{Kind: typst.DockerErrorUnknown}, typst.FallbackActionFallback},
+		{"Pool full", &typst.PoolFullError{MaxQueued: 1}, typst.FallbackActionRetry},
+		{"Other", errors.New("failed to decode query result"), typst.FallbackActionFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := typst.ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFallbackCaller(t *testing.T) {
+	missing := &fakeCaller{err: &exec.Error{Name: "typst", Err: exec.ErrNotFound}}
+	flaky := &flakyCaller{fakeCaller: &fakeCaller{}, err: &typst.DockerError{Kind: typst.DockerErrorDaemonUnreachable}}
+	flaky.failures.Store(2)
+
+	caller := &typst.FallbackCaller{
+		Callers: []typst.Caller{missing, flaky},
+		Retries: 2,
+		Backoff: time.Millisecond,
+	}
+
+	var report typst.FallbackReport
+	var w bytes.Buffer
+	if err := caller.CompileWithContext(typst.WithFallbackReport(context.Background(), &report), bytes.NewBufferString("Hello"), &w, nil); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+	if w.String() != "compiled: Hello" {
+		t.Errorf("Unexpected output %q.", w.String())
+	}
+
+	if report.Index != 1 || report.Caller != flaky {
+		t.Errorf("Unexpected backend %d, want %d.", report.Index, 1)
+	}
+	if len(report.Attempts) != 3 {
+		t.Errorf("Unexpected number of failed attempts. Got %d, want %d.", len(report.Attempts), 3)
+	}
+	if n := missing.compilations.Load(); n != 1 {
+		t.Errorf("Unexpected number of compilations of the first backend. Got %d, want %d.", n, 1)
+	}
+	if n := flaky.compilations.Load(); n != 3 {
+		t.Errorf("Unexpected number of compilations of the second backend. Got %d, want %d.", n, 3)
+	}
+}
+
+func TestFallbackCaller_TypstError(t *testing.T) {
+	broken := &fakeCaller{err: &typst.Error{Details: []typst.ErrorDetails{{Message: "unknown variable: foo"}}}}
+	other := &fakeCaller{}
+	caller := &typst.FallbackCaller{Callers: []typst.Caller{broken, other}, Retries: 2}
+
+	var w bytes.Buffer
+	err := caller.Compile(bytes.NewBufferString("#foo"), &w, nil)
+	var typstErr *typst.Error
+	if !errors.As(err, &typstErr) {
+		t.Fatalf("Expected *typst.Error, got %v.", err)
+	}
+
+	// Errors in the document must neither be retried, nor passed to other backends.
+	if n := broken.compilations.Load(); n != 1 {
+		t.Errorf("Unexpected number of compilations. Got %d, want %d.", n, 1)
+	}
+	if n := other.compilations.Load(); n != 0 {
+		t.Errorf("Unexpected number of compilations of the fallback. Got %d, want %d.", n, 0)
+	}
+}
+
+func TestFallbackCaller_CircuitBreaker(t *testing.T) {
+	broken := &fakeCaller{err: &typst.DockerError{Kind: typst.DockerErrorImageNotFound}}
+	other := &fakeCaller{}
+	caller := &typst.FallbackCaller{
+		Callers:          []typst.Caller{broken, other},
+		FailureThreshold: 2,
+		Cooldown:         50 * time.Millisecond,
+	}
+
+	compile := func() {
+		var w bytes.Buffer
+		if err := caller.Compile(bytes.NewBufferString("Hello"), &w, nil); err != nil {
+			t.Fatalf("Failed to compile document: %v.", err)
+		}
+	}
+
+	for range 4 {
+		compile()
+	}
+	if n := broken.compilations.Load(); n != 2 {
+		t.Errorf("Unexpected number of compilations of the broken backend. Got %d, want %d.", n, 2)
+	}
+
+	// After the cooldown, the backend is tried once again.
+	time.Sleep(60 * time.Millisecond)
+	compile()
+	compile()
+	if n := broken.compilations.Load(); n != 3 {
+		t.Errorf("Unexpected number of compilations of the broken backend. Got %d, want %d.", n, 3)
+	}
+	if n := other.compilations.Load(); n != 6 {
+		t.Errorf("Unexpected number of compilations of the fallback. Got %d, want %d.", n, 6)
+	}
+}
+
+func TestFallbackCaller_AllFailed(t *testing.T) {
+	caller := &typst.FallbackCaller{Callers: []typst.Caller{
+		&fakeCaller{err: &exec.Error{Name: "typst", Err: exec.ErrNotFound}},
+		&fakeCaller{err: &typst.DockerError{Kind: typst.DockerErrorPermissionDenied}},
+	}}
+
+	var w bytes.Buffer
+	err := caller.Compile(bytes.NewBufferString("Hello"), &w, nil)
+
+	var fallbackErr *typst.FallbackError
+	if !errors.As(err, &fallbackErr) || len(fallbackErr.Attempts) != 2 {
+		t.Fatalf("Expected *typst.FallbackError with 2 attempts, got %v.", err)
+	}
+	var dockerErr *typst.DockerError
+	if !errors.As(err, &dockerErr) || dockerErr.Kind != typst.DockerErrorPermissionDenied {
+		t.Errorf("Expected error to wrap the *typst.DockerError, got %v.", err)
+	}
+}
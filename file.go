@@ -0,0 +1,67 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// InputFile can be passed as input to Compile and Query, so that Typst reads the document from the file at the given path instead of stdin.
+//
+// This allows relative imports to be resolved against the directory of the file, and diagnostics will contain the actual file path.
+// The path is passed to Typst as is, so it's resolved by Typst itself:
+//   - typst.CLI resolves relative paths against its working directory.
+//   - typst.Docker and typst.DockerExec resolve the path inside of the container.
+//
+// Example:
+//
+//	err := typstCaller.Compile(typst.InputFile("templates/main.typ"), output, options)
+type InputFile string
+
+// Read always returns an error, as InputFile is only a marker that has to be passed to a Caller.
+func (f InputFile) Read(p []byte) (int, error) {
+	return 0, fmt.Errorf("typst.InputFile %q can't be read from, it can only be passed to a Caller", string(f))
+}
+
+// OutputFile can be passed as output to Compile, so that Typst writes the result into the file at the given path instead of stdout.
+//
+// The path is always a path on the host, even for container based callers.
+// Relative paths are resolved against the current working directory of this process.
+//
+// The file is written atomically: Typst writes into a temporary directory next to the destination, and the result is renamed into place once the compilation has succeeded.
+// If the Format in OptionsCompile is not set, it is inferred from the file extension.
+//
+// The file name can contain the templates {p}, {0p} and {t}, which are replaced by the page number, the zero-padded page number and the total number of pages.
+// This is needed to export multi-page documents as PNG or SVG.
+//
+// Example:
+//
+//	err := typstCaller.Compile(input, typst.OutputFile("output/page-{0p}.png"), nil)
+type OutputFile string
+
+// Write always returns an error, as OutputFile is only a marker that has to be passed to a Caller.
+func (f OutputFile) Write(p []byte) (int, error) {
+	return 0, fmt.Errorf("typst.OutputFile %q can't be written to, it can only be passed to a Caller", string(f))
+}
+
+// outputFormatFromPath returns the output format that corresponds to the extension of the given path.
+// If the extension is unknown, OutputFormatAuto is returned.
+func outputFormatFromPath(path string) OutputFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pdf":
+		return OutputFormatPDF
+	case ".png":
+		return OutputFormatPNG
+	case ".svg":
+		return OutputFormatSVG
+	case ".html", ".htm":
+		return OutputFormatHTML
+	}
+
+	return OutputFormatAuto
+}
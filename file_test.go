@@ -0,0 +1,125 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Dadido3/go-typst"
+)
+
+func TestCLI_CompileFiles(t *testing.T) {
+	cli := typst.CLI{}
+
+	outputPath := filepath.Join(t.TempDir(), "output.png")
+
+	if err := cli.Compile(typst.InputFile(filepath.Join("test-files", "hello-world.typ")), typst.OutputFile(outputPath), nil); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+
+	f, err := os.Open(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to open output file: %v.", err)
+	}
+	defer f.Close()
+
+	// The format has to be inferred from the file extension.
+	if _, imgType, err := image.DecodeConfig(f); err != nil {
+		t.Fatalf("Failed to decode image: %v.", err)
+	} else if imgType != "png" {
+		t.Errorf("Resulting image is of type %q, expected %q.", imgType, "png")
+	}
+
+	// There must not be any temporary files left.
+	if entries, err := os.ReadDir(filepath.Dir(outputPath)); err != nil {
+		t.Fatalf("Failed to read output directory: %v.", err)
+	} else if len(entries) != 1 {
+		t.Errorf("Unexpected number of files in output directory. Got %d, want %d.", len(entries), 1)
+	}
+}
+
+func TestCLI_CompileFilesRelativeImport(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "main.typ"), []byte(`#import "template.typ": template
+#show: doc => template()`), 0644); err != nil {
+		t.Fatalf("Failed to write input file: %v.", err)
+	}
+	template, err := os.ReadFile(filepath.Join("test-files", "hello-world-template.typ"))
+	if err != nil {
+		t.Fatalf("Failed to read template: %v.", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "template.typ"), template, 0644); err != nil {
+		t.Fatalf("Failed to write template: %v.", err)
+	}
+
+	cli := typst.CLI{}
+
+	var w bytes.Buffer
+	if err := cli.Compile(typst.InputFile(filepath.Join(dir, "main.typ")), &w, nil); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+	if w.Len() == 0 {
+		t.Errorf("No output was written.")
+	}
+}
+
+func TestCLI_CompileFilesPages(t *testing.T) {
+	cli := typst.CLI{}
+
+	dir := t.TempDir()
+
+	r := bytes.NewBufferString(`#set page(width: 1in, height: 1in)
+A #pagebreak() B #pagebreak() C`)
+
+	if err := cli.Compile(r, typst.OutputFile(filepath.Join(dir, "page-{0p}-of-{t}.svg")), nil); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+
+	for _, name := range []string{"page-1-of-3.svg", "page-2-of-3.svg", "page-3-of-3.svg"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected output file %q: %v.", name, err)
+		}
+	}
+}
+
+func TestCLI_CompileFilesError(t *testing.T) {
+	cli := typst.CLI{}
+
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "main.typ")
+
+	if err := os.WriteFile(inputPath, []byte(`#assert(1 < 1, message: "Test")`), 0644); err != nil {
+		t.Fatalf("Failed to write input file: %v.", err)
+	}
+
+	err := cli.Compile(typst.InputFile(inputPath), typst.OutputFile(filepath.Join(dir, "output.pdf")), nil)
+	var errTypst *typst.Error
+	if !errors.As(err, &errTypst) {
+		t.Fatalf("Expected error type %T, got %T: %v.", errTypst, err, err)
+	}
+	if len(errTypst.Details) != 1 || filepath.Base(errTypst.Details[0].Path) != "main.typ" {
+		t.Errorf("Unexpected error details: %v.", errTypst.Details)
+	}
+
+	// Neither the output, nor any temporary files must be left.
+	if entries, err := os.ReadDir(dir); err != nil {
+		t.Fatalf("Failed to read output directory: %v.", err)
+	} else if len(entries) != 1 {
+		t.Errorf("Unexpected number of files in output directory. Got %d, want %d.", len(entries), 1)
+	}
+}
+
+func TestInputFile_Read(t *testing.T) {
+	if _, err := typst.InputFile("foo.typ").Read(make([]byte, 10)); err == nil {
+		t.Errorf("Expected error, but got nil.")
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// fontStretchRegex matches the stretch of a font variant, like "FontStretch(1000)" or "100%".
+var fontStretchRegex = regexp.MustCompile(`^(?:FontStretch\((?<permille>\d+)\)|(?<percent>[\d.]+)%)$`)
+
+// FontFamily is a font family as listed by the fonts command of Typst.
+type FontFamily struct {
+	Name     string        // The name of the font family, like "Libertinus Serif".
+	Variants []FontVariant // The style variants of the family. Only available when the fonts were listed with typst.OptionsFonts.Variants set.
+}
+
+// FontVariant is a single style variant of a font family.
+type FontVariant struct {
+	Style   string  // The style of the variant, like "Normal", "Italic" or "Oblique".
+	Weight  int     // The weight of the variant, like 400 for regular or 700 for bold.
+	Stretch float64 // The relative width of the variant, where 1 is the normal width. Zero value means that there is no further information.
+	Path    string  // Path of the font file. Zero value means that there is no further information, as not all Typst versions report it.
+}
+
+// HasVariant returns whether the family contains a variant with the given style and weight.
+// The style is compared case-insensitively, an empty style matches any style.
+func (f FontFamily) HasVariant(style string, weight int) bool {
+	for _, variant := range f.Variants {
+		if variant.Weight == weight && (style == "" || strings.EqualFold(variant.Style, style)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFonts parses the lines that are returned by the Fonts method of a Caller into font families.
+//
+// Lines that start with a dash are parsed as variants of the preceding family, like "- Style: Normal, Weight: 400, Stretch: FontStretch(1000)".
+// Unknown properties of variants are ignored.
+func ParseFonts(lines []string) ([]FontFamily, error) {
+	var result []FontFamily
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		description, isVariant := strings.CutPrefix(trimmed, "- ")
+		if !isVariant {
+			result = append(result, FontFamily{Name: trimmed})
+			continue
+		}
+
+		if len(result) == 0 {
+			return nil, fmt.Errorf("font variant in line %d without preceding font family", i+1)
+		}
+
+		variant, err := parseFontVariant(description)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse font variant in line %d: %w", i+1, err)
+		}
+
+		family := &result[len(result)-1]
+		family.Variants = append(family.Variants, variant)
+	}
+
+	return result, nil
+}
+
+// parseFontVariant parses the comma separated properties of a font variant.
+func parseFontVariant(description string) (FontVariant, error) {
+	var variant FontVariant
+
+	// The path is the last property, and may contain commas itself.
+	if before, path, found := strings.Cut(description, "Path: "); found {
+		variant.Path = strings.TrimSpace(path)
+		description = strings.TrimSuffix(strings.TrimSpace(before), ",")
+	}
+
+	for _, property := range strings.Split(description, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(property), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.TrimSpace(key) {
+		case "Style":
+			variant.Style = value
+		case "Weight":
+			weight, err := strconv.Atoi(value)
+			if err != nil {
+				return FontVariant{}, fmt.Errorf("invalid weight %q: %w", value, err)
+			}
+			variant.Weight = weight
+		case "Stretch":
+			stretch, err := parseFontStretch(value)
+			if err != nil {
+				return FontVariant{}, err
+			}
+			variant.Stretch = stretch
+		}
+	}
+
+	return variant, nil
+}
+
+// parseFontStretch parses the stretch of a font variant into a relative width.
+func parseFontStretch(value string) (float64, error) {
+	match := fontStretchRegex.FindStringSubmatch(value)
+	if match == nil {
+		return 0, fmt.Errorf("invalid stretch %q", value)
+	}
+
+	if permille := match[fontStretchRegex.SubexpIndex("permille")]; permille != "" {
+		n, err := strconv.ParseFloat(permille, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid stretch %q: %w", value, err)
+		}
+		return n / 1000, nil
+	}
+
+	n, err := strconv.ParseFloat(match[fontStretchRegex.SubexpIndex("percent")], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid stretch %q: %w", value, err)
+	}
+	return n / 100, nil
+}
+
+// FontFamilies returns all font families that are available to Typst, including their style variants.
+//
+// The options parameter is optional, and can be nil.
+// Variants are always listed, regardless of the Variants field in options.
+func FontFamilies(ctx context.Context, caller Caller, options *OptionsFonts) ([]FontFamily, error) {
+	var optionsCopy OptionsFonts
+	if options != nil {
+		optionsCopy = *options
+	}
+	optionsCopy.Variants = true
+
+	lines, err := fontsWithContext(ctx, caller, &optionsCopy)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseFonts(lines)
+}
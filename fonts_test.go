@@ -0,0 +1,87 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Dadido3/go-typst"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFonts(t *testing.T) {
+	lines := []string{
+		"DejaVu Sans Mono",
+		"- Style: Normal, Weight: 400, Stretch: FontStretch(1000)",
+		"- Style: Oblique, Weight: 700, Stretch: FontStretch(1000)",
+		"Libertinus Serif",
+		"- Style: Italic, Weight: 600, Stretch: 100%, Path: /usr/share/fonts/libertinus, serif/LibertinusSerif-SemiboldItalic.otf",
+		"New Computer Modern",
+	}
+
+	want := []typst.FontFamily{
+		{Name: "DejaVu Sans Mono", Variants: []typst.FontVariant{
+			{Style: "Normal", Weight: 400, Stretch: 1},
+			{Style: "Oblique", Weight: 700, Stretch: 1},
+		}},
+		{Name: "Libertinus Serif", Variants: []typst.FontVariant{
+			{Style: "Italic", Weight: 600, Stretch: 1, Path: "/usr/share/fonts/libertinus, serif/LibertinusSerif-SemiboldItalic.otf"},
+		}},
+		{Name: "New Computer Modern"},
+	}
+
+	got, err := typst.ParseFonts(lines)
+	if err != nil {
+		t.Fatalf("Failed to parse fonts: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseFonts() mismatch (-want +got):\n%s", diff)
+	}
+
+	if !got[0].HasVariant("oblique", 700) {
+		t.Errorf("Expected family %q to have an oblique bold variant", got[0].Name)
+	}
+	if got[0].HasVariant("", 600) {
+		t.Errorf("Expected family %q to have no variant with weight 600", got[0].Name)
+	}
+}
+
+func TestParseFontsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+	}{
+		{"variant without family", []string{"- Style: Normal, Weight: 400, Stretch: FontStretch(1000)"}},
+		{"invalid weight", []string{"Foo", "- Style: Normal, Weight: bold, Stretch: FontStretch(1000)"}},
+		{"invalid stretch", []string{"Foo", "- Style: Normal, Weight: 400, Stretch: wide"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := typst.ParseFonts(tt.lines); err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestFontFamilies(t *testing.T) {
+	typstCaller := typst.CLI{}
+
+	families, err := typst.FontFamilies(context.Background(), typstCaller, &typst.OptionsFonts{IgnoreSystemFonts: true, FontPaths: []string{filepath.Join(".", "test-files")}})
+	if err != nil {
+		t.Fatalf("Failed to get available font families: %v.", err)
+	}
+	if len(families) != 5 {
+		t.Errorf("Unexpected number of detected font families. Got %d, want %d.", len(families), 5)
+	}
+	for _, family := range families {
+		if len(family.Variants) == 0 {
+			t.Errorf("Font family %q has no variants.", family.Name)
+		}
+	}
+}
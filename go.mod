@@ -5,6 +5,7 @@ go 1.23.0
 require (
 	github.com/google/go-cmp v0.6.0
 	github.com/smasher164/xid v0.1.2
+	github.com/tetratelabs/wazero v1.9.0
 )
 
 require golang.org/x/text v0.3.3 // indirect
@@ -0,0 +1,121 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// CallerKind identifies the kind of caller that has invoked Typst.
+type CallerKind string
+
+const (
+	CallerKindCLI             CallerKind = "cli"              // See typst.CLI.
+	CallerKindDocker          CallerKind = "docker"           // See typst.Docker.
+	CallerKindDockerExec      CallerKind = "docker-exec"      // See typst.DockerExec.
+	CallerKindDockerContainer CallerKind = "docker-container" // See typst.DockerContainer.
+	CallerKindWASM            CallerKind = "wasm"             // See typst.WASM.
+)
+
+// InvocationInfo describes a single invocation of Typst.
+//
+// The same object is passed to Hooks.BeforeInvocation and Hooks.AfterInvocation, so it can be used to correlate both calls.
+type InvocationInfo struct {
+	Kind  CallerKind // The kind of caller that has invoked Typst.
+	Args  []string   // The arguments that are passed to Typst, like the ones returned by OptionsCompile.Args. Must not be modified.
+	Start time.Time  // The time the invocation has started.
+
+	// The following fields are only set once the invocation has finished.
+
+	Duration    time.Duration  // The wall time of the invocation.
+	ExitCode    int            // The exit code of Typst, or of the container runtime if it has failed. -1 if there is no exit code, e.g. because the process couldn't be started or was killed.
+	StdoutBytes int64          // The number of bytes that Typst has written to stdout.
+	Stderr      string         // The raw output that Typst has written to stderr.
+	Diagnostics []ErrorDetails // The parsed errors or warnings from stderr.
+	Err         error          // The error that is returned to the caller, or nil.
+}
+
+// Hooks receives events for every invocation of Typst by a caller.
+//
+// Set it via the Hooks field of typst.CLI, typst.Docker, typst.DockerExec, typst.DockerContainer or typst.WASM.
+// Typst in watch mode is not reported.
+// Implementations must be safe for concurrent use.
+type Hooks interface {
+	// BeforeInvocation is called right before Typst is invoked.
+	// Only Kind, Args and Start of info are set.
+	BeforeInvocation(ctx context.Context, info *InvocationInfo)
+
+	// AfterInvocation is called once the invocation has finished, successful or not.
+	AfterInvocation(ctx context.Context, info *InvocationInfo)
+}
+
+// SlogHooks is an implementation of Hooks that logs every invocation via log/slog.
+//
+// The start of an invocation is logged with debug level.
+// Finished invocations are logged with Level, and failed invocations with warning level.
+type SlogHooks struct {
+	Logger *slog.Logger // The logger to use. Defaults to slog.Default() if nil.
+	Level  slog.Level   // The level of successful invocations. Defaults to slog.LevelInfo.
+}
+
+// Ensure that SlogHooks implements the Hooks interface.
+var _ Hooks = SlogHooks{}
+
+// logger returns the logger to use.
+func (h SlogHooks) logger() *slog.Logger {
+	if h.Logger != nil {
+		return h.Logger
+	}
+	return slog.Default()
+}
+
+// BeforeInvocation implements the Hooks interface.
+func (h SlogHooks) BeforeInvocation(ctx context.Context, info *InvocationInfo) {
+	h.logger().LogAttrs(ctx, slog.LevelDebug, "Invoking Typst",
+		slog.String("kind", string(info.Kind)),
+		slog.Any("args", info.Args),
+	)
+}
+
+// AfterInvocation implements the Hooks interface.
+func (h SlogHooks) AfterInvocation(ctx context.Context, info *InvocationInfo) {
+	attrs := []slog.Attr{
+		slog.String("kind", string(info.Kind)),
+		slog.Any("args", info.Args),
+		slog.Duration("duration", info.Duration),
+		slog.Int("exit_code", info.ExitCode),
+		slog.Int64("stdout_bytes", info.StdoutBytes),
+	}
+	if info.Stderr != "" {
+		attrs = append(attrs, slog.String("stderr", info.Stderr))
+	}
+	if len(info.Diagnostics) > 0 {
+		attrs = append(attrs, slog.Any("diagnostics", info.Diagnostics))
+	}
+
+	if info.Err != nil {
+		attrs = append(attrs, slog.Any("error", info.Err))
+		h.logger().LogAttrs(ctx, slog.LevelWarn, "Typst invocation failed", attrs...)
+		return
+	}
+
+	h.logger().LogAttrs(ctx, h.Level, "Typst invocation finished", attrs...)
+}
+
+// countingWriter passes all writes to w, and counts the written bytes.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/Dadido3/go-typst"
+)
+
+// recordingHooks collects all finished invocations.
+type recordingHooks struct {
+	mutex    sync.Mutex
+	started  int
+	finished []typst.InvocationInfo
+}
+
+func (h *recordingHooks) BeforeInvocation(ctx context.Context, info *typst.InvocationInfo) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.started++
+}
+
+func (h *recordingHooks) AfterInvocation(ctx context.Context, info *typst.InvocationInfo) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.finished = append(h.finished, *info)
+}
+
+func TestHooks(t *testing.T) {
+	hooks := &recordingHooks{}
+	typstCaller := typst.CLI{Hooks: hooks}
+
+	var w bytes.Buffer
+	if err := typstCaller.Compile(bytes.NewBufferString(`Hello World`), &w, &typst.OptionsCompile{Format: typst.OutputFormatSVG}); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+	if err := typstCaller.Compile(bytes.NewBufferString(`#assert(1 < 1, message: "Test")`), &w, nil); err == nil {
+		t.Fatalf("Expected error, but got nil.")
+	}
+
+	if hooks.started != 2 || len(hooks.finished) != 2 {
+		t.Fatalf("Unexpected number of invocations. Got %d started and %d finished, want %d.", hooks.started, len(hooks.finished), 2)
+	}
+
+	success, failure := hooks.finished[0], hooks.finished[1]
+	if success.Kind != typst.CallerKindCLI {
+		t.Errorf("Unexpected caller kind %q, want %q.", success.Kind, typst.CallerKindCLI)
+	}
+	if !slices.Contains(success.Args, "svg") {
+		t.Errorf("Expected arguments %q to contain the format.", success.Args)
+	}
+	if success.ExitCode != 0 || success.Err != nil || success.StdoutBytes == 0 || success.Duration <= 0 {
+		t.Errorf("Unexpected info of successful invocation: %+v.", success)
+	}
+	if failure.ExitCode != 1 || failure.Err == nil || failure.Stderr == "" || len(failure.Diagnostics) == 0 {
+		t.Errorf("Unexpected info of failed invocation: %+v.", failure)
+	}
+}
+
+func TestSlogHooks(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	typstCaller := typst.CLI{Hooks: typst.SlogHooks{Logger: logger}}
+
+	if _, err := typstCaller.VersionString(); err != nil {
+		t.Fatalf("Failed to get typst version: %v.", err)
+	}
+
+	var records []map[string]any
+	decoder := json.NewDecoder(&logs)
+	for decoder.More() {
+		var record map[string]any
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("Failed to decode log record: %v.", err)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Unexpected number of log records. Got %d, want %d.", len(records), 2)
+	}
+	if records[0]["level"] != "DEBUG" || records[1]["level"] != "INFO" {
+		t.Errorf("Unexpected log levels %v and %v.", records[0]["level"], records[1]["level"])
+	}
+	if records[1]["kind"] != "cli" || records[1]["exit_code"] != float64(0) {
+		t.Errorf("Unexpected log record %v.", records[1])
+	}
+}
@@ -6,7 +6,10 @@
 package typst
 
 import (
+	"io/fs"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
 	"time"
 )
@@ -22,6 +25,13 @@ const (
 	OutputFormatHTML OutputFormat = "html" // this format is only available since 0.13.0
 )
 
+type DiagnosticFormat string
+
+const (
+	DiagnosticFormatShort DiagnosticFormat = "short" // One line per diagnostic, which can be parsed reliably. This is used by default.
+	DiagnosticFormatHuman DiagnosticFormat = "human" // Multiple lines per diagnostic with excerpts of the source, which is meant to be displayed to humans.
+)
+
 type PDFStandard string
 
 const (
@@ -101,6 +111,21 @@ type OptionsCompile struct {
 	PackageCachePath    string            // Custom path to package cache, defaults to system-dependent location.
 	Jobs                int               // Number of parallel jobs spawned during compilation, defaults to number of CPUs. Setting it to 1 disables parallelism.
 
+	// Optional virtual project that is made available to Typst, like an embed.FS with templates, images and data.
+	//
+	// The files are copied into a private temporary directory that is used as project root, so Root has to be empty.
+	// Imports and other relative paths of the document resolve against the root of the project, and typst.InputFile refers to a file inside of the project.
+	// Symbolic links and paths outside of the project are rejected.
+	Project fs.FS
+
+	// Optional in-memory files that are made available to Typst, like generated charts or CSV data.
+	// The keys are slash separated paths relative to the project root, like "data/rows.csv".
+	//
+	// They are written into the same private temporary directory as Project, which is used as project root, so Root has to be empty.
+	// The files must not collide with files of Project.
+	// The directory is removed once the compilation has finished, even if it has failed or was aborted.
+	Files map[string][]byte
+
 	// Which pages to export. When unspecified, all document pages are exported.
 	//
 	// Pages to export are separated by commas, and can be either simple page numbers (e.g. '2,5' to export only pages 2 and 5) or page ranges (e.g. '2,3-6,8-' to export page 2, pages 3 to 6 (inclusive), page 8 and any pages after it).
@@ -116,20 +141,193 @@ type OptionsCompile struct {
 	// See typst.PDFStandard for possible values.
 	PDFStandards []PDFStandard
 
+	// The format of the diagnostics that Typst emits. Defaults to typst.DiagnosticFormatShort.
+	//
+	// Both formats are parsed into the details of typst.Error.
+	// Only the human format contains the end positions of erroneous spans, and is better suited for displaying the raw output.
+	DiagnosticFormat DiagnosticFormat
+
+	// Optional function that receives the warnings that Typst emitted during a successful compilation, like unknown font families or deprecated syntax.
+	// It is only called if there are any warnings.
+	// When the compilation fails, the warnings are part of the details of the returned typst.Error instead.
+	WarningHandler func(warnings []ErrorDetails)
+
 	Custom []string // Custom command line options go here.
 }
 
 // Args returns a list of CLI arguments that should be passed to the executable.
+//
+// Some arguments depend on the Typst version, these are generated for the latest supported version.
 func (o *OptionsCompile) Args() (result []string) {
+	// Use stdio for input and output.
+	return o.args("c", "-", "-", latestVersion.Capabilities())
+}
+
+// args returns a list of CLI arguments for the given command, and the given input and output paths.
+// The output path is optional, and can be empty.
+// The capabilities of the Typst version decide about version dependent arguments.
+func (o *OptionsCompile) args(command, input, output string, capabilities Capabilities) (result []string) {
+	// The first argument is the command we want to run.
+	result = []string{command}
+
+	result = append(result, worldOptions{
+		Root:                o.Root,
+		Input:               o.Input,
+		FontPaths:           o.FontPaths,
+		IgnoreSystemFonts:   o.IgnoreSystemFonts,
+		IgnoreEmbeddedFonts: o.IgnoreEmbeddedFonts,
+		CreationTime:        o.CreationTime,
+		PackagePath:         o.PackagePath,
+		PackageCachePath:    o.PackageCachePath,
+	}.args()...)
+
+	if o.NoPDFTags {
+		result = append(result, "--no-pdf-tags")
+	}
+
+	if o.Jobs > 0 {
+		result = append(result, "-j", strconv.FormatInt(int64(o.Jobs), 10))
+	}
+
+	if o.Pages != "" {
+		result = append(result, "--pages", o.Pages)
+	}
+
+	if o.Format != OutputFormatAuto {
+		result = append(result, "-f", string(o.Format))
+		if o.Format == OutputFormatHTML {
+			if capabilities.HTMLFeatureFlag {
+				// HTML export is still experimental, and needs to be activated explicitly.
+				result = append(result, "--features", "html")
+			}
+			if command == "w" {
+				// Typst would otherwise start an HTTP server for live previews.
+				result = append(result, "--no-serve")
+			}
+		}
+	}
+
+	if o.PPI > 0 {
+		result = append(result, "--ppi", strconv.FormatInt(int64(o.PPI), 10))
+	}
+
+	if len(o.PDFStandards) > 0 {
+		var standards string
+		for i, standard := range o.PDFStandards {
+			if i > 0 {
+				standards += ","
+			}
+			standards += string(standard)
+		}
+		result = append(result, "--pdf-standard", standards)
+	}
+
+	result = append(result, o.DiagnosticFormat.args()...)
+
+	result = append(result, o.Custom...)
+
+	result = append(result, input)
+	if output != "" {
+		result = append(result, output)
+	}
+
+	return
+}
+
+// OptionsQuery contains all supported parameters for the query command.
+//
+// The result of a query is always requested in the JSON format, so that it can be decoded into Go values.
+type OptionsQuery struct {
+	Root                string            // Configures the project root (for absolute paths).
+	Input               map[string]string // String key-value pairs visible through `sys.inputs`.
+	FontPaths           []string          // Adds additional directories that are recursively searched for fonts.
+	IgnoreSystemFonts   bool              // Ensures system fonts won't be searched, unless explicitly included via FontPaths.
+	IgnoreEmbeddedFonts bool              // Disables the use of fonts embedded into the Typst binary. (Available since Typst 0.14.0)
+	CreationTime        time.Time         // The document's creation date. For more information, see https://reproducible-builds.org/specs/source-date-epoch/.
+	PackagePath         string            // Custom path to local packages, defaults to system-dependent location.
+	PackageCachePath    string            // Custom path to package cache, defaults to system-dependent location.
+
+	// Defines which elements to retrieve.
+	// This can be any selector that is supported by Typst's query function, like `<label>`, `heading` or `heading.where(level: 1)`.
+	//
+	// This field is mandatory.
+	Selector string
+
+	Field string // Extracts just one field from all retrieved elements.
+	One   bool   // Expects and retrieves exactly one element.
+
+	DiagnosticFormat DiagnosticFormat // The format of the diagnostics that Typst emits. Defaults to typst.DiagnosticFormatShort.
+
+	// Optional function that receives the warnings that Typst emitted during a successful query.
+	// It is only called if there are any warnings.
+	WarningHandler func(warnings []ErrorDetails)
+
+	Custom []string // Custom command line options go here.
+}
+
+// Args returns a list of CLI arguments that should be passed to the executable.
+func (o *OptionsQuery) Args() (result []string) {
+	// Use stdin as input.
+	return o.args("-")
+}
+
+// args returns a list of CLI arguments for the given input path.
+func (o *OptionsQuery) args(input string) (result []string) {
 	// The first argument is the command we want to run.
-	result = []string{"c"}
+	result = []string{"query"}
+
+	result = append(result, worldOptions{
+		Root:                o.Root,
+		Input:               o.Input,
+		FontPaths:           o.FontPaths,
+		IgnoreSystemFonts:   o.IgnoreSystemFonts,
+		IgnoreEmbeddedFonts: o.IgnoreEmbeddedFonts,
+		CreationTime:        o.CreationTime,
+		PackagePath:         o.PackagePath,
+		PackageCachePath:    o.PackageCachePath,
+	}.args()...)
+
+	if o.Field != "" {
+		result = append(result, "--field", o.Field)
+	}
 
+	if o.One {
+		result = append(result, "--one")
+	}
+
+	// We always want JSON, as that's what we decode the result from.
+	result = append(result, "--format", "json")
+
+	result = append(result, o.DiagnosticFormat.args()...)
+
+	result = append(result, o.Custom...)
+
+	result = append(result, input, o.Selector)
+
+	return
+}
+
+// worldOptions contains the parameters that are shared by all commands which set up a Typst world.
+type worldOptions struct {
+	Root                string
+	Input               map[string]string
+	FontPaths           []string
+	IgnoreSystemFonts   bool
+	IgnoreEmbeddedFonts bool
+	CreationTime        time.Time
+	PackagePath         string
+	PackageCachePath    string
+}
+
+// args returns a list of CLI arguments that configure the Typst world.
+func (o worldOptions) args() (result []string) {
 	if o.Root != "" {
 		result = append(result, "--root", o.Root)
 	}
 
-	for key, value := range o.Input {
-		result = append(result, "--input", key+"="+value)
+	// Iterate over the sorted keys, so that the arguments are deterministic.
+	for _, key := range slices.Sorted(maps.Keys(o.Input)) {
+		result = append(result, "--input", key+"="+o.Input[key])
 	}
 
 	if len(o.FontPaths) > 0 {
@@ -151,10 +349,6 @@ func (o *OptionsCompile) Args() (result []string) {
 		result = append(result, "--ignore-embedded-fonts")
 	}
 
-	if o.NoPDFTags {
-		result = append(result, "--no-pdf-tags")
-	}
-
 	if !o.CreationTime.IsZero() {
 		result = append(result, "--creation-timestamp", strconv.FormatInt(o.CreationTime.Unix(), 10))
 	}
@@ -167,48 +361,14 @@ func (o *OptionsCompile) Args() (result []string) {
 		result = append(result, "--package-cache-path", o.PackageCachePath)
 	}
 
-	if o.Jobs > 0 {
-		result = append(result, "-j", strconv.FormatInt(int64(o.Jobs), 10))
-	}
-
-	if o.Pages != "" {
-		result = append(result, "--pages", o.Pages)
-	}
-
-	if o.Format != OutputFormatAuto {
-		result = append(result, "-f", string(o.Format))
-		if o.Format == OutputFormatHTML {
-			// this is specific to version 0.13.0 where html
-			// is a feature than need explicit activation
-			// we must remove this when html becomes standard
-			result = append(result, "--features", "html")
-		}
-	}
-
-	if o.PPI > 0 {
-		result = append(result, "--ppi", strconv.FormatInt(int64(o.PPI), 10))
-	}
+	return
+}
 
-	if len(o.PDFStandards) > 0 {
-		var standards string
-		for i, standard := range o.PDFStandards {
-			if i > 0 {
-				standards += ","
-			}
-			standards += string(standard)
-		}
-		result = append(result, "--pdf-standard", standards)
+// args returns the CLI arguments that select the diagnostic format.
+func (f DiagnosticFormat) args() []string {
+	if f == "" {
+		f = DiagnosticFormatShort
 	}
 
-	// Use human diagnostic format, as that's the format that we support right now.
-	// TODO: Switch to a different diagnostic format in the future
-	result = append(result, "--diagnostic-format", "human")
-
-	result = append(result, o.Custom...)
-
-	// Use stdio for input and output.
-	// TODO: Add Args parameters for when we want to use files instead
-	result = append(result, "-", "-")
-
-	return
+	return []string{"--diagnostic-format", string(f)}
 }
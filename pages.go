@@ -0,0 +1,172 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// pageFileRegex matches the file names that are created by the output template in CompilePages.
+var pageFileRegex = regexp.MustCompile(`^page-(?<page>\d+)-of-(?<total>\d+)\.(?:png|svg)$`)
+
+// compiledPage describes a single page file that Typst has written.
+type compiledPage struct {
+	number int    // One-indexed page number.
+	path   string // Path of the file on the host.
+}
+
+// CompilePages takes a Typst document from input, and renders every page into a separate writer.
+// This is done with a single compilation, no matter how many pages the document has.
+//
+// The format in options has to be set to typst.OutputFormatPNG or typst.OutputFormatSVG.
+// If Pages is set in options, only the selected pages are rendered.
+//
+// For every rendered page, pageWriter is called in ascending page order with the one-indexed page number.
+// It has to return the writer that the page is written to.
+// If the returned writer implements io.Closer, it will be closed after the page has been written.
+//
+// Internally the pages are written into a temporary directory, which is removed afterwards.
+func CompilePages(ctx context.Context, caller Caller, input io.Reader, options *OptionsCompile, pageWriter func(page int) (io.Writer, error)) error {
+	if options == nil {
+		options = new(OptionsCompile)
+	}
+
+	var ext string
+	switch options.Format {
+	case OutputFormatPNG:
+		ext = ".png"
+	case OutputFormatSVG:
+		ext = ".svg"
+	default:
+		return fmt.Errorf("unsupported output format %q, only %q and %q are supported", options.Format, OutputFormatPNG, OutputFormatSVG)
+	}
+
+	dir, err := os.MkdirTemp("", "go-typst-pages-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := compileWithContext(ctx, caller, input, OutputFile(filepath.Join(dir, "page-{0p}-of-{t}"+ext)), options); err != nil {
+		return err
+	}
+
+	pages, err := collectPages(dir, options.Pages == "")
+	if err != nil {
+		return err
+	}
+
+	for _, page := range pages {
+		if err := writePage(page, pageWriter); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CompilePagesBytes takes a Typst document from input, and returns the data of every rendered page in ascending page order.
+//
+// See CompilePages for details.
+func CompilePagesBytes(ctx context.Context, caller Caller, input io.Reader, options *OptionsCompile) ([][]byte, error) {
+	var buffers []*bytes.Buffer
+	err := CompilePages(ctx, caller, input, options, func(page int) (io.Writer, error) {
+		buffer := new(bytes.Buffer)
+		buffers = append(buffers, buffer)
+		return buffer, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([][]byte, 0, len(buffers))
+	for _, buffer := range buffers {
+		result = append(result, buffer.Bytes())
+	}
+
+	return result, nil
+}
+
+// collectPages returns all page files inside of dir sorted by their page number.
+// If all is true, it is checked that every page of the document is present.
+func collectPages(dir string, all bool) ([]compiledPage, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read temporary directory: %w", err)
+	}
+
+	var pages []compiledPage
+	total := -1
+	for _, entry := range entries {
+		match := pageFileRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected file %q in temporary directory", entry.Name())
+		}
+
+		number, err := strconv.Atoi(match[pageFileRegex.SubexpIndex("page")])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse page number of %q: %w", entry.Name(), err)
+		}
+		entryTotal, err := strconv.Atoi(match[pageFileRegex.SubexpIndex("total")])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse page count of %q: %w", entry.Name(), err)
+		}
+		if total >= 0 && total != entryTotal {
+			return nil, fmt.Errorf("inconsistent page count in %q: got %d, want %d", entry.Name(), entryTotal, total)
+		}
+		total = entryTotal
+
+		pages = append(pages, compiledPage{number: number, path: filepath.Join(dir, entry.Name())})
+	}
+
+	slices.SortFunc(pages, func(a, b compiledPage) int { return cmp.Compare(a.number, b.number) })
+
+	if all && len(pages) != max(total, 0) {
+		return nil, fmt.Errorf("typst has written %d pages, but the document has %d pages", len(pages), total)
+	}
+	for i, page := range pages {
+		if page.number < 1 || page.number > total || (i > 0 && pages[i-1].number == page.number) {
+			return nil, fmt.Errorf("unexpected page number %d of %d pages", page.number, total)
+		}
+	}
+
+	return pages, nil
+}
+
+// writePage copies the content of the given page file into the writer that pageWriter returns.
+func writePage(page compiledPage, pageWriter func(page int) (io.Writer, error)) error {
+	f, err := os.Open(page.path)
+	if err != nil {
+		return fmt.Errorf("failed to open page %d: %w", page.number, err)
+	}
+	defer f.Close()
+
+	w, err := pageWriter(page.number)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("failed to write page %d: %w", page.number, err)
+	}
+
+	if closer, ok := w.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return fmt.Errorf("failed to close writer of page %d: %w", page.number, err)
+		}
+	}
+
+	return nil
+}
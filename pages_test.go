@@ -0,0 +1,69 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/Dadido3/go-typst"
+)
+
+const threePageDocument = `#set page(width: 1in, height: 1in)
+A #pagebreak() B #pagebreak() C`
+
+func TestCompilePagesBytes(t *testing.T) {
+	cli := typst.CLI{}
+
+	pages, err := typst.CompilePagesBytes(context.Background(), cli, bytes.NewBufferString(threePageDocument), &typst.OptionsCompile{Format: typst.OutputFormatPNG, PPI: 72})
+	if err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+	if len(pages) != 3 {
+		t.Fatalf("Unexpected number of pages. Got %d, want %d.", len(pages), 3)
+	}
+
+	for i, page := range pages {
+		imgConf, imgType, err := image.DecodeConfig(bytes.NewReader(page))
+		if err != nil {
+			t.Fatalf("Failed to decode page %d: %v.", i+1, err)
+		}
+		if imgType != "png" {
+			t.Errorf("Page %d is of type %q, expected %q.", i+1, imgType, "png")
+		}
+		if imgConf.Width != 72 {
+			t.Errorf("Page %d has a width of %d, expected %d.", i+1, imgConf.Width, 72)
+		}
+	}
+}
+
+func TestCompilePagesSelection(t *testing.T) {
+	cli := typst.CLI{}
+
+	var numbers []int
+	err := typst.CompilePages(context.Background(), cli, bytes.NewBufferString(threePageDocument), &typst.OptionsCompile{Format: typst.OutputFormatSVG, Pages: "2-"}, func(page int) (io.Writer, error) {
+		numbers = append(numbers, page)
+		return io.Discard, nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+	if want := []int{2, 3}; !slices.Equal(numbers, want) {
+		t.Errorf("Unexpected page numbers. Got %v, want %v.", numbers, want)
+	}
+}
+
+func TestCompilePagesUnsupportedFormat(t *testing.T) {
+	cli := typst.CLI{}
+
+	if _, err := typst.CompilePagesBytes(context.Background(), cli, bytes.NewBufferString(threePageDocument), &typst.OptionsCompile{Format: typst.OutputFormatPDF}); err == nil {
+		t.Errorf("Expected error, but got nil.")
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+// Podman returns a typst.Docker caller that is preconfigured for rootless Podman.
+//
+// The user namespace is set to "keep-id", so that the user inside of the container is mapped to the current user.
+// All volumes are relabeled, so that they can be accessed on SELinux enabled hosts.
+// The returned caller can be customized further, like any other typst.Docker caller.
+func Podman() Docker {
+	return Docker{
+		Executable:     "podman",
+		UserNamespace:  "keep-id",
+		SELinuxRelabel: true,
+	}
+}
+
+// PodmanExec returns a typst.DockerExec caller that invokes Typst in the running Podman container with the given name.
+// The returned caller can be customized further, like any other typst.DockerExec caller.
+func PodmanExec(containerName string) DockerExec {
+	return DockerExec{
+		Executable:    "podman",
+		ContainerName: containerName,
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+//go:build unix
+
+package typst_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Dadido3/go-typst"
+)
+
+// fakeRuntime puts a fake container runtime executable with the given name and shell script body on PATH.
+// It returns the path of a file that receives the arguments of every invocation.
+func fakeRuntime(t *testing.T, name, script string) string {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "calls.log")
+
+	content := "#!/bin/sh\necho \"$@\" >> " + logPath + "\n" + script + "\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0755); err != nil {
+		t.Fatalf("Failed to write fake runtime: %v.", err)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return logPath
+}
+
+// readCalls returns all logged invocations of a fake runtime.
+func readCalls(t *testing.T, logPath string) []string {
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read calls of fake runtime: %v.", err)
+	}
+
+	return strings.Split(strings.TrimSpace(string(content)), "\n")
+}
+
+func TestPodman_Args(t *testing.T) {
+	logPath := fakeRuntime(t, "podman", `echo "typst 0.14.0 (b790c6d5)"`)
+
+	typstCaller := typst.Podman()
+	typstCaller.Volumes = []string{"/fonts:/usr/share/fonts", "/data:/data:ro", "/cache"}
+
+	if _, err := typstCaller.VersionString(); err != nil {
+		t.Fatalf("Failed to get typst version: %v.", err)
+	}
+
+	calls := readCalls(t, logPath)
+	if len(calls) != 1 {
+		t.Fatalf("Unexpected number of invocations. Got %d, want %d.", len(calls), 1)
+	}
+	for _, want := range []string{"run -i --name go-typst-", " --userns keep-id ", " -v /fonts:/usr/share/fonts:Z -v /data:/data:ro,Z -v /cache ", typst.DockerDefaultImage + " --version"} {
+		if !strings.Contains(calls[0], want) {
+			t.Errorf("Invocation %q doesn't contain %q.", calls[0], want)
+		}
+	}
+}
+
+func TestPodmanExec_ContainerNotFound(t *testing.T) {
+	fakeRuntime(t, "podman", `echo 'Error: no container with name or ID "typst-instance" found: no such container' >&2; exit 125`)
+
+	_, err := typst.PodmanExec("typst-instance").VersionString()
+	if err == nil {
+		t.Fatalf("Expected error, but got nil.")
+	}
+
+	var errDocker *typst.DockerError
+	if !errors.As(err, &errDocker) {
+		t.Fatalf("Expected error of type %T, got %T: %v.", errDocker, err, err)
+	}
+	if errDocker.Kind != typst.DockerErrorContainerNotFound {
+		t.Errorf("Unexpected error kind. Got %q, want %q.", errDocker.Kind, typst.DockerErrorContainerNotFound)
+	}
+	if errDocker.ExitCode != 125 {
+		t.Errorf("Unexpected exit code. Got %d, want %d.", errDocker.ExitCode, 125)
+	}
+}
+
+func TestPodmanExec_TypstError(t *testing.T) {
+	fakeRuntime(t, "podman", `echo '<stdin>:1:2: error: unknown variable: foo' >&2; exit 1`)
+
+	_, err := typst.PodmanExec("typst-instance").VersionString()
+	if err == nil {
+		t.Fatalf("Expected error, but got nil.")
+	}
+
+	var errTypst *typst.Error
+	if !errors.As(err, &errTypst) {
+		t.Fatalf("Expected error of type %T, got %T: %v.", errTypst, err, err)
+	}
+	var errDocker *typst.DockerError
+	if errors.As(err, &errDocker) {
+		t.Errorf("Expected no error of type %T, got %v.", errDocker, errDocker)
+	}
+}
+
+func TestNerdctl_RuntimeError(t *testing.T) {
+	fakeRuntime(t, "nerdctl", `echo 'time="2025-10-17T12:00:00Z" level=fatal msg="no such container: typst-instance"' >&2; exit 1`)
+
+	_, err := typst.DockerExec{Executable: "nerdctl", ContainerName: "typst-instance"}.VersionString()
+
+	var errDocker *typst.DockerError
+	if !errors.As(err, &errDocker) {
+		t.Fatalf("Expected error of type %T, got %T: %v.", errDocker, err, err)
+	}
+	if errDocker.Kind != typst.DockerErrorContainerNotFound {
+		t.Errorf("Unexpected error kind. Got %q, want %q.", errDocker.Kind, typst.DockerErrorContainerNotFound)
+	}
+}
@@ -0,0 +1,382 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrPoolClosed is returned by a PoolCaller that has been closed.
+var ErrPoolClosed = errors.New("the pool has been closed")
+
+// PoolFullError is returned by a PoolCaller if an invocation is rejected because its queue is full.
+type PoolFullError struct {
+	MaxQueued int // The queue limit that was reached.
+}
+
+func (e *PoolFullError) Error() string {
+	return fmt.Sprintf("the pool queue is full: %d invocations are already waiting", e.MaxQueued)
+}
+
+// PoolStats contains statistics of a PoolCaller.
+type PoolStats struct {
+	Running int // Number of invocations that are currently running.
+	Queued  int // Number of invocations that are currently waiting for a free slot.
+
+	Started   uint64 // Number of invocations that got a slot.
+	Completed uint64 // Number of invocations that have finished, successful or not.
+	Rejected  uint64 // Number of invocations that were rejected because the queue was full or the pool was closed.
+	Abandoned uint64 // Number of invocations whose context was done while they were waiting in the queue.
+
+	TotalWait time.Duration // Accumulated time that started invocations have spent waiting in the queue.
+	MaxWait   time.Duration // Longest time an invocation has spent waiting in the queue.
+	TotalRun  time.Duration // Accumulated run time of all completed invocations.
+}
+
+// AverageWait returns the average time that started invocations have spent waiting in the queue.
+func (s PoolStats) AverageWait() time.Duration {
+	if s.Started == 0 {
+		return 0
+	}
+	return s.TotalWait / time.Duration(s.Started)
+}
+
+// AverageRun returns the average run time of all completed invocations.
+func (s PoolStats) AverageRun() time.Duration {
+	if s.Completed == 0 {
+		return 0
+	}
+	return s.TotalRun / time.Duration(s.Completed)
+}
+
+// priorityContextKey is the context key for the priority of an invocation.
+type priorityContextKey struct{}
+
+// WithPriority returns a copy of ctx that carries the given priority.
+// PoolCaller starts queued invocations with a higher priority first.
+// Invocations without priority have a priority of 0.
+func WithPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityContextKey{}, priority)
+}
+
+// priorityFromContext returns the priority that is stored in ctx, or 0.
+func priorityFromContext(ctx context.Context) int {
+	priority, _ := ctx.Value(priorityContextKey{}).(int)
+	return priority
+}
+
+// PoolCaller wraps another Caller, and limits the number of concurrent invocations.
+//
+// Invocations that exceed the limit are queued, and started in order of their priority (see typst.WithPriority).
+// Invocations with the same priority are started in the order they arrived.
+// If the context of a queued invocation is done, it is removed from the queue.
+//
+// If PoolCaller wraps a CachingCaller, cache hits occupy a slot, too.
+// Wrap the PoolCaller with the CachingCaller instead, if this is not wanted.
+//
+// Create it with &typst.PoolCaller{Caller: typst.CLI{}, MaxConcurrent: 4}, and don't copy or modify it after first use.
+// It is safe for concurrent use.
+type PoolCaller struct {
+	Caller Caller // The caller that is wrapped.
+
+	// The maximum number of invocations that run at the same time.
+	// Defaults to the number of CPUs.
+	MaxConcurrent int
+
+	// The maximum number of invocations that wait for a free slot.
+	// Further invocations are rejected with a *typst.PoolFullError.
+	// If 0, the queue is unlimited.
+	MaxQueued int
+
+	// If set, OptionsCompile.Jobs is limited to this value.
+	// As Typst uses all CPUs by default, this keeps concurrent compilations from competing for them.
+	Jobs int
+
+	mutex   sync.Mutex
+	queue   poolQueue
+	seq     uint64        // Sequence number of the last queued invocation.
+	closed  bool          // Whether the pool doesn't accept new invocations.
+	drained chan struct{} // Closed once the pool is closed and all invocations have finished. Nil until Close is called.
+	stats   PoolStats
+}
+
+// poolWaiter is an invocation that waits for a free slot.
+type poolWaiter struct {
+	priority int
+	seq      uint64
+	index    int           // The index inside of the queue, or -1 if it was removed.
+	queued   time.Time     // The time the invocation was queued.
+	ready    chan struct{} // Closed once the invocation has got a slot.
+}
+
+// poolQueue is a priority queue of waiting invocations, which implements heap.Interface.
+type poolQueue []*poolWaiter
+
+func (q poolQueue) Len() int { return len(q) }
+
+func (q poolQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q poolQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+
+func (q *poolQueue) Push(x any) {
+	waiter := x.(*poolWaiter)
+	waiter.index = len(*q)
+	*q = append(*q, waiter)
+}
+
+func (q *poolQueue) Pop() any {
+	old := *q
+	waiter := old[len(old)-1]
+	old[len(old)-1] = nil
+	waiter.index = -1
+	*q = old[:len(old)-1]
+	return waiter
+}
+
+// Ensure that PoolCaller implements the ContextCaller and QueryCaller interfaces.
+var (
+	_ ContextCaller = &PoolCaller{}
+	_ QueryCaller   = &PoolCaller{}
+)
+
+// maxConcurrent returns the effective limit of concurrent invocations.
+func (p *PoolCaller) maxConcurrent() int {
+	if p.MaxConcurrent > 0 {
+		return p.MaxConcurrent
+	}
+	return runtime.NumCPU()
+}
+
+// acquire waits until there is a free slot, or until ctx is done.
+// Every successful call has to be followed by a call to release.
+func (p *PoolCaller) acquire(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("typst invocation was aborted: %w", err)
+	}
+
+	p.mutex.Lock()
+	if p.closed {
+		p.stats.Rejected++
+		p.mutex.Unlock()
+		return ErrPoolClosed
+	}
+	if p.stats.Running < p.maxConcurrent() && p.queue.Len() == 0 {
+		p.stats.Running++
+		p.stats.Started++
+		p.mutex.Unlock()
+		return nil
+	}
+	if p.MaxQueued > 0 && p.queue.Len() >= p.MaxQueued {
+		p.stats.Rejected++
+		p.mutex.Unlock()
+		return &PoolFullError{MaxQueued: p.MaxQueued}
+	}
+
+	p.seq++
+	waiter := &poolWaiter{
+		priority: priorityFromContext(ctx),
+		seq:      p.seq,
+		queued:   time.Now(),
+		ready:    make(chan struct{}),
+	}
+	heap.Push(&p.queue, waiter)
+	p.stats.Queued = p.queue.Len()
+	p.mutex.Unlock()
+
+	select {
+	case <-waiter.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.stats.Abandoned++
+	if waiter.index < 0 {
+		// The slot was granted at the same time, so pass it on.
+		p.freeSlot()
+	} else {
+		heap.Remove(&p.queue, waiter.index)
+		p.stats.Queued = p.queue.Len()
+		p.checkDrained()
+	}
+
+	return fmt.Errorf("typst invocation was aborted: %w", ctx.Err())
+}
+
+// release frees the slot of an invocation that ran for the given duration.
+func (p *PoolCaller) release(duration time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.stats.Completed++
+	p.stats.TotalRun += duration
+	p.freeSlot()
+}
+
+// freeSlot frees the slot of an invocation, and passes it to the next queued invocation.
+// The mutex has to be held by the caller.
+func (p *PoolCaller) freeSlot() {
+	p.stats.Running--
+
+	for p.queue.Len() > 0 && p.stats.Running < p.maxConcurrent() {
+		waiter := heap.Pop(&p.queue).(*poolWaiter)
+		wait := time.Since(waiter.queued)
+		p.stats.TotalWait += wait
+		p.stats.MaxWait = max(p.stats.MaxWait, wait)
+		p.stats.Running++
+		p.stats.Started++
+		close(waiter.ready)
+	}
+	p.stats.Queued = p.queue.Len()
+
+	p.checkDrained()
+}
+
+// checkDrained signals Close once the pool is closed and idle.
+// The mutex has to be held by the caller.
+func (p *PoolCaller) checkDrained() {
+	if !p.closed || p.stats.Running > 0 || p.queue.Len() > 0 {
+		return
+	}
+	select {
+	case <-p.drained:
+	default:
+		close(p.drained)
+	}
+}
+
+// do runs f inside of a slot.
+func (p *PoolCaller) do(ctx context.Context, f func() error) error {
+	if err := p.acquire(ctx); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	defer func() { p.release(time.Since(start)) }()
+
+	return f()
+}
+
+// Stats returns a snapshot of the statistics of the pool.
+func (p *PoolCaller) Stats() PoolStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.stats
+}
+
+// Close stops the pool from accepting new invocations, and waits until all running and queued invocations have finished.
+// New invocations are rejected with typst.ErrPoolClosed.
+//
+// If ctx is done before the pool is drained, Close returns the error of ctx, and the remaining invocations continue in the background.
+func (p *PoolCaller) Close(ctx context.Context) error {
+	p.mutex.Lock()
+	if !p.closed {
+		p.closed = true
+		p.drained = make(chan struct{})
+		p.checkDrained()
+	}
+	drained := p.drained
+	p.mutex.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to drain the pool: %w", ctx.Err())
+	}
+}
+
+// VersionString returns the Typst version as a string.
+func (p *PoolCaller) VersionString() (string, error) {
+	return p.VersionStringWithContext(context.Background())
+}
+
+// VersionStringWithContext returns the Typst version as a string.
+func (p *PoolCaller) VersionStringWithContext(ctx context.Context) (string, error) {
+	var result string
+	err := p.do(ctx, func() (err error) {
+		result, err = versionStringWithContext(ctx, p.Caller)
+		return err
+	})
+	return result, err
+}
+
+// Fonts returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+func (p *PoolCaller) Fonts(options *OptionsFonts) ([]string, error) {
+	return p.FontsWithContext(context.Background(), options)
+}
+
+// FontsWithContext returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+func (p *PoolCaller) FontsWithContext(ctx context.Context, options *OptionsFonts) ([]string, error) {
+	var result []string
+	err := p.do(ctx, func() (err error) {
+		result, err = fontsWithContext(ctx, p.Caller, options)
+		return err
+	})
+	return result, err
+}
+
+// Compile takes a Typst document from input, and renders it into the output writer.
+// The options parameter is optional, and can be nil.
+func (p *PoolCaller) Compile(input io.Reader, output io.Writer, options *OptionsCompile) error {
+	return p.CompileWithContext(context.Background(), input, output, options)
+}
+
+// CompileWithContext takes a Typst document from input, and renders it into the output writer.
+// The number of parallel jobs is limited to PoolCaller.Jobs, if set.
+// The options parameter is optional, and can be nil.
+func (p *PoolCaller) CompileWithContext(ctx context.Context, input io.Reader, output io.Writer, options *OptionsCompile) error {
+	if p.Jobs > 0 {
+		var optionsCopy OptionsCompile
+		if options != nil {
+			optionsCopy = *options
+		}
+		if optionsCopy.Jobs <= 0 || optionsCopy.Jobs > p.Jobs {
+			optionsCopy.Jobs = p.Jobs
+		}
+		options = &optionsCopy
+	}
+
+	return p.do(ctx, func() error {
+		return compileWithContext(ctx, p.Caller, input, output, options)
+	})
+}
+
+// Query takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+func (p *PoolCaller) Query(input io.Reader, result any, options *OptionsQuery) error {
+	return p.QueryWithContext(context.Background(), input, result, options)
+}
+
+// QueryWithContext takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+func (p *PoolCaller) QueryWithContext(ctx context.Context, input io.Reader, result any, options *OptionsQuery) error {
+	return p.do(ctx, func() error {
+		return queryWithContext(ctx, p.Caller, input, result, options)
+	})
+}
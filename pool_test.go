@@ -0,0 +1,183 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Dadido3/go-typst"
+)
+
+func TestPoolCaller(t *testing.T) {
+	fake := &fakeCaller{delay: 50 * time.Millisecond}
+	pool := &typst.PoolCaller{Caller: fake, MaxConcurrent: 2, Jobs: 2}
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var w bytes.Buffer
+			if err := pool.Compile(bytes.NewBufferString("Hello"), &w, &typst.OptionsCompile{Jobs: 8}); err != nil {
+				t.Errorf("Failed to compile document: %v.", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := fake.maxRunning.Load(); n != 2 {
+		t.Errorf("Unexpected number of concurrent compilations. Got %d, want %d.", n, 2)
+	}
+	if n := fake.jobs.Load(); n != 2 {
+		t.Errorf("Unexpected number of jobs. Got %d, want %d.", n, 2)
+	}
+
+	stats := pool.Stats()
+	if stats.Started != 6 || stats.Completed != 6 || stats.Running != 0 || stats.Queued != 0 {
+		t.Errorf("Unexpected stats %+v.", stats)
+	}
+	if stats.MaxWait <= 0 || stats.AverageRun() <= 0 {
+		t.Errorf("Expected wait and run times to be recorded, got %+v.", stats)
+	}
+}
+
+func TestPoolCaller_Priority(t *testing.T) {
+	fake := &fakeCaller{delay: 50 * time.Millisecond}
+	pool := &typst.PoolCaller{Caller: fake, MaxConcurrent: 1}
+
+	var mutex sync.Mutex
+	var order []string
+
+	compile := func(wg *sync.WaitGroup, name string, priority int) {
+		defer wg.Done()
+
+		var w bytes.Buffer
+		if err := pool.CompileWithContext(typst.WithPriority(context.Background(), priority), bytes.NewBufferString(name), &w, nil); err != nil {
+			t.Errorf("Failed to compile document: %v.", err)
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		order = append(order, name)
+	}
+
+	// Occupy the only slot, so that the following invocations are queued.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go compile(&wg, "first", 0)
+	time.Sleep(10 * time.Millisecond)
+
+	for _, item := range []struct {
+		name     string
+		priority int
+	}{{"low", -1}, {"normal", 0}, {"high", 10}} {
+		wg.Add(1)
+		go compile(&wg, item.name, item.priority)
+		time.Sleep(10 * time.Millisecond)
+	}
+	wg.Wait()
+
+	want := []string{"first", "high", "normal", "low"}
+	for i := range want {
+		if i >= len(order) || order[i] != want[i] {
+			t.Fatalf("Unexpected order. Got %v, want %v.", order, want)
+		}
+	}
+}
+
+func TestPoolCaller_QueueFull(t *testing.T) {
+	fake := &fakeCaller{delay: 100 * time.Millisecond}
+	pool := &typst.PoolCaller{Caller: fake, MaxConcurrent: 1, MaxQueued: 1}
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var w bytes.Buffer
+			if err := pool.Compile(bytes.NewBufferString("Hello"), &w, nil); err != nil {
+				t.Errorf("Failed to compile document: %v.", err)
+			}
+		}()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var w bytes.Buffer
+	err := pool.Compile(bytes.NewBufferString("Hello"), &w, nil)
+	var fullErr *typst.PoolFullError
+	if !errors.As(err, &fullErr) {
+		t.Errorf("Expected *typst.PoolFullError, got %v.", err)
+	}
+	wg.Wait()
+
+	if stats := pool.Stats(); stats.Rejected != 1 {
+		t.Errorf("Unexpected number of rejected invocations. Got %d, want %d.", stats.Rejected, 1)
+	}
+}
+
+func TestPoolCaller_Abandon(t *testing.T) {
+	fake := &fakeCaller{delay: 100 * time.Millisecond}
+	pool := &typst.PoolCaller{Caller: fake, MaxConcurrent: 1}
+
+	go func() {
+		var w bytes.Buffer
+		pool.Compile(bytes.NewBufferString("Hello"), &w, nil) //nolint:errcheck
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var w bytes.Buffer
+	if err := pool.CompileWithContext(ctx, bytes.NewBufferString("Hello"), &w, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error to wrap context.DeadlineExceeded, got %v.", err)
+	}
+	if stats := pool.Stats(); stats.Queued != 0 || stats.Abandoned != 1 {
+		t.Errorf("Unexpected stats %+v.", stats)
+	}
+}
+
+func TestPoolCaller_Close(t *testing.T) {
+	fake := &fakeCaller{delay: 50 * time.Millisecond}
+	pool := &typst.PoolCaller{Caller: fake, MaxConcurrent: 1}
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var w bytes.Buffer
+			if err := pool.Compile(bytes.NewBufferString("Hello"), &w, nil); err != nil {
+				t.Errorf("Failed to compile document: %v.", err)
+			}
+		}()
+	}
+	for stats := pool.Stats(); stats.Running+stats.Queued < 3; stats = pool.Stats() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Queued invocations are still finished.
+	if err := pool.Close(context.Background()); err != nil {
+		t.Fatalf("Failed to close pool: %v.", err)
+	}
+	if n := fake.compilations.Load(); n != 3 {
+		t.Errorf("Unexpected number of compilations. Got %d, want %d.", n, 3)
+	}
+	wg.Wait()
+
+	var w bytes.Buffer
+	if err := pool.Compile(bytes.NewBufferString("Hello"), &w, nil); !errors.Is(err, typst.ErrPoolClosed) {
+		t.Errorf("Expected typst.ErrPoolClosed, got %v.", err)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// checkProjectPath returns an error if name is not a local path inside of a project.
+func checkProjectPath(name string) error {
+	if !fs.ValidPath(name) || !filepath.IsLocal(filepath.FromSlash(name)) {
+		return fmt.Errorf("path %q is outside of the project", name)
+	}
+
+	return nil
+}
+
+// newProjectWorkspace creates a workspace with all files of the project in fsys and the given in-memory files, and makes it available to Typst.
+// Both fsys and files are optional.
+func newProjectWorkspace(ctx context.Context, r runner, fsys fs.FS, files map[string][]byte) (*workspace, error) {
+	ws, err := r.newWorkspace(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if fsys != nil {
+		if err := writeProject(fsys, ws.hostDir); err != nil {
+			ws.close() //nolint:errcheck
+			return nil, err
+		}
+	}
+
+	if err := writeFiles(files, ws.hostDir); err != nil {
+		ws.close() //nolint:errcheck
+		return nil, err
+	}
+
+	if ws.push != nil {
+		if err := ws.push(ctx); err != nil {
+			ws.close() //nolint:errcheck
+			return nil, err
+		}
+	}
+
+	return ws, nil
+}
+
+// writeProject copies all files of fsys into the host directory dir.
+//
+// Symbolic links, irregular files and paths that would end up outside of dir are rejected.
+func writeProject(fsys fs.FS, dir string) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to read project: %w", err)
+		}
+		if name == "." {
+			return nil
+		}
+		if err := checkProjectPath(name); err != nil {
+			return err
+		}
+
+		hostPath := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch {
+		case d.IsDir():
+			if err := os.Mkdir(hostPath, 0755); err != nil {
+				return fmt.Errorf("failed to create project directory: %w", err)
+			}
+			return nil
+		case d.Type()&fs.ModeSymlink != 0:
+			return fmt.Errorf("project file %q is a symbolic link, which is not supported", name)
+		case !d.Type().IsRegular():
+			return fmt.Errorf("project file %q is not a regular file", name)
+		}
+
+		return writeProjectFile(fsys, name, hostPath)
+	})
+}
+
+// writeFiles writes the given in-memory files into the host directory dir.
+// Existing files are not overwritten.
+func writeFiles(files map[string][]byte, dir string) error {
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		if err := checkProjectPath(name); err != nil {
+			return err
+		}
+
+		hostPath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(hostPath), 0755); err != nil {
+			return fmt.Errorf("failed to create project directory: %w", err)
+		}
+
+		f, err := os.OpenFile(hostPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			if errors.Is(err, fs.ErrExist) {
+				return fmt.Errorf("file %q collides with a file of the project", name)
+			}
+			return fmt.Errorf("failed to create project file: %w", err)
+		}
+
+		_, err = f.Write(files[name])
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write project file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// writeProjectFile copies the file with the given name from fsys to hostPath.
+func writeProjectFile(fsys fs.FS, name, hostPath string) error {
+	src, err := fsys.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open project file: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(hostPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create project file: %w", err)
+	}
+
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write project file: %w", err)
+	}
+
+	return nil
+}
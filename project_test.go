@@ -0,0 +1,180 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Dadido3/go-typst"
+)
+
+// testProject returns a virtual project with a module, a data file and an image.
+func testProject(t *testing.T) fstest.MapFS {
+	image, err := os.ReadFile(filepath.Join(".", "test-files", "test.png"))
+	if err != nil {
+		t.Fatalf("Failed to read image: %v.", err)
+	}
+
+	return fstest.MapFS{
+		"main.typ":        {Data: []byte(`#include "chapter.typ"`)},
+		"chapter.typ":     {Data: []byte(`#import "lib/values.typ": greeting` + "\n" + `#assert.eq(greeting, "Hello")` + "\n" + `#assert.eq(read("data/text.txt"), "World")` + "\n" + `#image("images/test.png")`)},
+		"lib/values.typ":  {Data: []byte(`#let greeting = "Hello"`)},
+		"data/text.txt":   {Data: []byte(`World`)},
+		"images/test.png": {Data: image},
+	}
+}
+
+// testCompileProject compiles a virtual project with the given caller, once from stdin and once via typst.InputFile.
+func testCompileProject(t *testing.T, typstCaller typst.Caller) {
+	options := typst.OptionsCompile{Project: testProject(t), Format: typst.OutputFormatSVG}
+
+	var w bytes.Buffer
+	if err := typstCaller.Compile(bytes.NewBufferString(`#include "chapter.typ"`), &w, &options); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+	if w.Len() == 0 {
+		t.Errorf("Expected output, but got nothing.")
+	}
+
+	w.Reset()
+	if err := typstCaller.Compile(typst.InputFile("main.typ"), &w, &options); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+	if w.Len() == 0 {
+		t.Errorf("Expected output, but got nothing.")
+	}
+}
+
+func TestCLI_CompileProject(t *testing.T) {
+	testCompileProject(t, typst.CLI{})
+}
+
+func TestDocker_CompileProject(t *testing.T) {
+	testCompileProject(t, typst.Docker{Image: typstDockerImage()})
+}
+
+func TestCompileProject_Rejected(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.typ"), []byte(`Hello`), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v.", err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "main.typ"), filepath.Join(dir, "link.typ")); err != nil {
+		t.Skipf("Failed to create symbolic link: %v.", err)
+	}
+
+	typstCaller := typst.CLI{}
+
+	tests := []struct {
+		name    string
+		input   typst.InputFile
+		options typst.OptionsCompile
+		want    string
+	}{
+		{"Traversal", "../main.typ", typst.OptionsCompile{Project: testProject(t)}, `path "../main.typ" is outside of the project`},
+		{"Absolute", "/main.typ", typst.OptionsCompile{Project: testProject(t)}, `path "/main.typ" is outside of the project`},
+		{"Symlink", "main.typ", typst.OptionsCompile{Project: os.DirFS(dir)}, `project file "link.typ" is a symbolic link`},
+		{"Root", "main.typ", typst.OptionsCompile{Root: ".", Project: testProject(t)}, "Root can't be used together with Project"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w bytes.Buffer
+			err := typstCaller.Compile(tt.input, &w, &tt.options)
+			if err == nil {
+				t.Fatalf("Expected error, but got nil.")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Unexpected error %q, want it to contain %q.", err, tt.want)
+			}
+		})
+	}
+}
+
+// testCompileAttachments compiles a document that references in-memory files with the given caller.
+func testCompileAttachments(t *testing.T, typstCaller typst.Caller) {
+	image, err := os.ReadFile(filepath.Join(".", "test-files", "test.png"))
+	if err != nil {
+		t.Fatalf("Failed to read image: %v.", err)
+	}
+
+	r := bytes.NewBufferString(`#include "chapter.typ"
+#assert.eq(csv("rows.csv").len(), 2)
+#image("charts/chart.png")`)
+
+	options := typst.OptionsCompile{
+		Project: testProject(t),
+		Files: map[string][]byte{
+			"rows.csv":         []byte("a,1\nb,2\n"),
+			"charts/chart.png": image,
+		},
+		Format: typst.OutputFormatSVG,
+	}
+
+	var w bytes.Buffer
+	if err := typstCaller.Compile(r, &w, &options); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+	if w.Len() == 0 {
+		t.Errorf("Expected output, but got nothing.")
+	}
+}
+
+func TestCLI_CompileAttachments(t *testing.T) {
+	testCompileAttachments(t, typst.CLI{})
+}
+
+func TestDocker_CompileAttachments(t *testing.T) {
+	testCompileAttachments(t, typst.Docker{Image: typstDockerImage()})
+}
+
+func TestCompileAttachments_Collision(t *testing.T) {
+	options := typst.OptionsCompile{
+		Project: testProject(t),
+		Files:   map[string][]byte{"data/text.txt": []byte("Other")},
+	}
+
+	var w bytes.Buffer
+	err := (typst.CLI{}).Compile(bytes.NewBufferString(`Hello`), &w, &options)
+	if err == nil {
+		t.Fatalf("Expected error, but got nil.")
+	}
+	if want := `file "data/text.txt" collides with a file of the project`; !strings.Contains(err.Error(), want) {
+		t.Errorf("Unexpected error %q, want it to contain %q.", err, want)
+	}
+}
+
+func TestCompileAttachments_Cleanup(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Neither an aborted nor a failed compilation may leave files behind.
+	var w bytes.Buffer
+	if err := (typst.CLI{}).CompileWithContext(ctx, bytes.NewBufferString(`Hello`), &w, &typst.OptionsCompile{Files: map[string][]byte{"a.txt": nil}}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error wrapping %v, got %v.", context.Canceled, err)
+	}
+	err := (typst.CLI{}).Compile(bytes.NewBufferString(`Hello`), &w, &typst.OptionsCompile{Files: map[string][]byte{"a.txt": nil, "../b.txt": nil}})
+	if want := `path "../b.txt" is outside of the project`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Unexpected error %v, want it to contain %q.", err, want)
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to read temporary directory: %v.", err)
+	}
+	if len(entries) > 0 {
+		t.Errorf("Expected temporary directory to be empty, got %d entries.", len(entries))
+	}
+}
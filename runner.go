@@ -0,0 +1,388 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CancelGracePeriod is the time a Typst process is given to terminate after its context is done.
+// After that period the process will be killed.
+var CancelGracePeriod = 5 * time.Second
+
+// invocation describes a single call of a Typst executable.
+type invocation struct {
+	args   []string  // Arguments that are passed to Typst.
+	stdin  io.Reader // Optional reader that is connected to stdin.
+	stdout io.Writer // Optional writer that is connected to stdout.
+	stderr io.Writer // Optional writer that is connected to stderr.
+
+	volumes []string // Additional volumes that container based callers need to mount.
+
+	warningHandler func(warnings []ErrorDetails) // Optional function that receives any diagnostics of a successful invocation.
+}
+
+// exitError is implemented by errors that describe a Typst invocation that has exited with a non-zero exit code.
+// This is the case for *exec.ExitError, and for the wrapped exit errors of WebAssembly modules.
+type exitError interface {
+	error
+	ExitCode() int
+}
+
+// runner is implemented by all callers that invoke Typst via some external process or module.
+type runner interface {
+	// run invokes Typst as described by inv and waits until it has finished.
+	//
+	// If Typst itself returns with an error, the returned error is or wraps an exitError.
+	run(ctx context.Context, inv *invocation) error
+
+	// newWorkspace creates a temporary directory inside of the given host directory, and makes it available to Typst.
+	newWorkspace(ctx context.Context, parent string) (*workspace, error)
+
+	// kind returns the kind of the caller, as it is reported to hooks.
+	kind() CallerKind
+
+	// hooks returns the hooks that are called for every invocation, or nil.
+	hooks() Hooks
+}
+
+// versionCacher is implemented by runners that always invoke the same Typst executable, so that its detected version can be kept.
+type versionCacher interface {
+	// versionCache returns the cache of the detected version.
+	versionCache() *versionCache
+}
+
+// versionCache contains the detected version of a Typst executable.
+// The zero value is an empty cache.
+type versionCache struct {
+	mutex   sync.Mutex
+	version Version
+	valid   bool
+}
+
+// load returns the cached version, and whether there is one.
+func (c *versionCache) load() (Version, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.version, c.valid
+}
+
+// store puts the given version into the cache.
+func (c *versionCache) store(version Version) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.version, c.valid = version, true
+}
+
+// reset empties the cache.
+func (c *versionCache) reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.version, c.valid = Version{}, false
+}
+
+// commandContext returns a command that is terminated gracefully once ctx is done.
+//
+// The optional cancel function is called right before the process is terminated.
+// If the process doesn't stop within CancelGracePeriod, it will be killed.
+func commandContext(ctx context.Context, cancel func(), name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Cancel = func() error {
+		if cancel != nil {
+			cancel()
+		}
+		return terminateProcess(cmd.Process)
+	}
+	cmd.WaitDelay = CancelGracePeriod
+
+	return cmd
+}
+
+// invoke runs the given invocation with r and turns any Typst related error into a typst.Error.
+// The hooks of r are called before and after the invocation.
+func invoke(ctx context.Context, r runner, inv *invocation) error {
+	hooks := r.hooks()
+	if hooks == nil {
+		_, err := invokeRunner(ctx, r, inv)
+		return err
+	}
+
+	info := &InvocationInfo{Kind: r.kind(), Args: inv.args, Start: time.Now()}
+	hooks.BeforeInvocation(ctx, info)
+
+	stdout := &countingWriter{w: inv.stdout}
+	if inv.stdout != nil {
+		inv.stdout = stdout
+	}
+
+	stderr, err := invokeRunner(ctx, r, inv)
+
+	info.Duration = time.Since(info.Start)
+	info.StdoutBytes = stdout.n
+	info.Stderr = stderr
+	info.Err = err
+	info.ExitCode = 0
+	if err != nil {
+		info.ExitCode = -1
+		var exitErr exitError
+		if errors.As(err, &exitErr) {
+			info.ExitCode = exitErr.ExitCode()
+		}
+	}
+	var typstErr *Error
+	if errors.As(err, &typstErr) {
+		info.Diagnostics = typstErr.Details
+	} else if err == nil && stderr != "" {
+		if typstErr, ok := ParseStderr(stderr, nil).(*Error); ok {
+			info.Diagnostics = typstErr.Details
+		}
+	}
+
+	hooks.AfterInvocation(ctx, info)
+
+	return err
+}
+
+// invokeRunner runs the given invocation with r and turns any Typst related error into a typst.Error.
+// It returns everything that Typst has written to stderr.
+func invokeRunner(ctx context.Context, r runner, inv *invocation) (string, error) {
+	var errBuffer bytes.Buffer
+	inv.stderr = &errBuffer
+
+	if err := r.run(ctx, inv); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return errBuffer.String(), fmt.Errorf("typst invocation was aborted: %w", ctxErr)
+		}
+
+		// Errors of the container runtime itself are not Typst errors, even if they wrap an exit error.
+		var dockerErr *DockerError
+		if errors.As(err, &dockerErr) {
+			return errBuffer.String(), err
+		}
+
+		var exitErr exitError
+		if errors.As(err, &exitErr) {
+			return errBuffer.String(), ParseStderr(errBuffer.String(), err)
+		}
+		return errBuffer.String(), err
+	}
+
+	// Typst can succeed, but still emit warnings.
+	if inv.warningHandler != nil && errBuffer.Len() > 0 {
+		if typstErr, ok := ParseStderr(errBuffer.String(), nil).(*Error); ok && len(typstErr.Details) > 0 {
+			inv.warningHandler(typstErr.Details)
+		}
+	}
+
+	return errBuffer.String(), nil
+}
+
+// versionString returns the version string of the Typst executable behind r.
+func versionString(ctx context.Context, r runner) (string, error) {
+	var output bytes.Buffer
+	if err := invoke(ctx, r, &invocation{args: []string{"--version"}, stdout: &output}); err != nil {
+		return "", err
+	}
+
+	return output.String(), nil
+}
+
+// runnerVersion returns the version of the Typst executable behind r.
+// The version is only detected once if r implements versionCacher, and on every call otherwise.
+func runnerVersion(ctx context.Context, r runner) (Version, error) {
+	var cache *versionCache
+	if cacher, ok := r.(versionCacher); ok {
+		cache = cacher.versionCache()
+		if version, ok := cache.load(); ok {
+			return version, nil
+		}
+	}
+
+	s, err := versionString(ctx, r)
+	if err != nil {
+		return Version{}, fmt.Errorf("failed to detect Typst version: %w", err)
+	}
+	version, err := ParseVersion(s)
+	if err != nil {
+		return Version{}, fmt.Errorf("failed to detect Typst version: %w", err)
+	}
+
+	if cache != nil {
+		cache.store(version)
+	}
+	return version, nil
+}
+
+// compileArgs returns the arguments of the given compile command for the Typst executable behind r.
+// The version of Typst is only detected if the arguments depend on it.
+func compileArgs(ctx context.Context, r runner, options *OptionsCompile, command, input, output string) ([]string, error) {
+	var capabilities Capabilities
+	if options.Format == OutputFormatHTML {
+		version, err := runnerVersion(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		capabilities = version.Capabilities()
+	}
+
+	return options.args(command, input, output, capabilities), nil
+}
+
+// fonts returns all fonts that are available to the Typst executable behind r.
+func fonts(ctx context.Context, r runner, options *OptionsFonts) ([]string, error) {
+	if options == nil {
+		options = new(OptionsFonts)
+	}
+
+	var output bytes.Buffer
+	if err := invoke(ctx, r, &invocation{args: options.Args(), stdout: &output}); err != nil {
+		return nil, err
+	}
+
+	var result []string
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		result = append(result, scanner.Text())
+	}
+
+	return result, nil
+}
+
+// compile lets the Typst executable behind r render the document from input into output.
+func compile(ctx context.Context, r runner, input io.Reader, output io.Writer, options *OptionsCompile) error {
+	if options == nil {
+		options = new(OptionsCompile)
+	}
+
+	inv := &invocation{stdout: output, warningHandler: options.WarningHandler}
+
+	inputPath := "-"
+	if file, ok := input.(InputFile); ok {
+		inputPath = string(file)
+	} else {
+		inv.stdin = input
+	}
+
+	if options.Project != nil || len(options.Files) > 0 {
+		if options.Root != "" {
+			return fmt.Errorf("the option Root can't be used together with Project or Files")
+		}
+		if inv.stdin == nil {
+			if err := checkProjectPath(inputPath); err != nil {
+				return err
+			}
+		}
+
+		project, err := newProjectWorkspace(ctx, r, options.Project, options.Files)
+		if err != nil {
+			return err
+		}
+		defer project.close() //nolint:errcheck
+
+		optionsCopy := *options
+		optionsCopy.Root = project.typstDir
+		options = &optionsCopy
+
+		if inv.stdin == nil {
+			inputPath = project.typstPath(inputPath)
+		}
+		if project.volume != "" {
+			inv.volumes = append(inv.volumes, project.volume)
+		}
+	}
+
+	file, ok := output.(OutputFile)
+	if !ok {
+		args, err := compileArgs(ctx, r, options, "c", inputPath, "-")
+		if err != nil {
+			return err
+		}
+		inv.args = args
+		return invoke(ctx, r, inv)
+	}
+	inv.stdout = nil
+
+	outputPath, err := filepath.Abs(string(file))
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path of output file: %w", err)
+	}
+	outputDir, outputName := filepath.Split(outputPath)
+
+	if options.Format == OutputFormatAuto {
+		optionsCopy := *options
+		optionsCopy.Format = outputFormatFromPath(outputName)
+		options = &optionsCopy
+	}
+
+	// Let Typst write into a temporary directory next to the destination.
+	ws, err := r.newWorkspace(ctx, outputDir)
+	if err != nil {
+		return err
+	}
+	defer ws.close() //nolint:errcheck
+
+	if inv.args, err = compileArgs(ctx, r, options, "c", inputPath, ws.typstPath(outputName)); err != nil {
+		return err
+	}
+	if ws.volume != "" {
+		inv.volumes = append(inv.volumes, ws.volume)
+	}
+	if err := invoke(ctx, r, inv); err != nil {
+		return err
+	}
+
+	if ws.pull != nil {
+		if err := ws.pull(ctx); err != nil {
+			return err
+		}
+	}
+
+	return ws.moveFiles(outputDir)
+}
+
+// query lets the Typst executable behind r query elements from the document in input, and decodes the JSON result into result.
+func query(ctx context.Context, r runner, input io.Reader, result any, options *OptionsQuery) error {
+	if options == nil || options.Selector == "" {
+		return fmt.Errorf("the selector of the query is empty")
+	}
+
+	var output bytes.Buffer
+	inv := &invocation{stdout: &output, warningHandler: options.WarningHandler}
+
+	inputPath := "-"
+	if file, ok := input.(InputFile); ok {
+		inputPath = string(file)
+	} else {
+		inv.stdin = input
+	}
+
+	inv.args = options.args(inputPath)
+	if err := invoke(ctx, r, inv); err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(output.Bytes(), result); err != nil {
+		return fmt.Errorf("failed to decode query result: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+//go:build wasip1
+
+// This is a fake Typst executable that is compiled to WebAssembly, so that the plumbing of typst.WASM can be tested without a real Typst module.
+//
+// It only supports "--version" and the compile command.
+// The compile command interprets the input document line by line:
+//
+//	args        Writes all command line arguments, one per line.
+//	env         Writes all environment variables, one per line.
+//	read <path> Writes the content of the file at the given path.
+//	exit <code> Writes a Typst error to stderr, and exits with the given code.
+//
+// All other lines are written as they are.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fail(2, "missing command")
+	}
+
+	switch os.Args[1] {
+	case "--version":
+		fmt.Println("typst 0.14.0 (00000000)")
+	case "c", "compile":
+		compile(os.Args[len(os.Args)-2], os.Args[len(os.Args)-1])
+	default:
+		fail(2, fmt.Sprintf("unsupported command %q", os.Args[1]))
+	}
+}
+
+func compile(inputPath, outputPath string) {
+	var input []byte
+	var err error
+	if inputPath == "-" {
+		input, err = io.ReadAll(os.Stdin)
+	} else {
+		input, err = os.ReadFile(inputPath)
+	}
+	if err != nil {
+		fail(1, err.Error())
+	}
+
+	var output strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(string(input)), "\n") {
+		command, argument, _ := strings.Cut(line, " ")
+		switch command {
+		case "args":
+			output.WriteString(strings.Join(os.Args[1:], "\n") + "\n")
+		case "env":
+			output.WriteString(strings.Join(os.Environ(), "\n") + "\n")
+		case "read":
+			content, err := os.ReadFile(argument)
+			if err != nil {
+				fail(1, err.Error())
+			}
+			output.Write(content)
+		case "exit":
+			code, err := strconv.Atoi(argument)
+			if err != nil {
+				fail(2, err.Error())
+			}
+			fail(code, "exit requested")
+		default:
+			output.WriteString(line + "\n")
+		}
+	}
+
+	if outputPath == "-" {
+		os.Stdout.WriteString(output.String())
+	} else if err := os.WriteFile(outputPath, []byte(output.String()), 0644); err != nil {
+		fail(1, err.Error())
+	}
+}
+
+// fail writes an error in the format of Typst to stderr, and exits with the given code.
+func fail(code int, message string) {
+	fmt.Fprintf(os.Stderr, "error: %s\n  ┌─ <stdin>:1:1\n  │\n1 │ \n  │ ^\n\n", message)
+	os.Exit(code)
+}
@@ -6,6 +6,8 @@
 package typst
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"maps"
@@ -45,3 +47,13 @@ func InjectValues(output io.Writer, values map[string]any) error {
 
 	return nil
 }
+
+// randomID returns a random hexadecimal string that can be used to create unique names.
+func randomID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+
+	return hex.EncodeToString(b[:])
+}
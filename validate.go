@@ -0,0 +1,383 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ValidationError describes a single option that is malformed, or not supported by a specific Typst version.
+//
+// The Validate methods of the options join all problems into one error, the single entries can be retrieved with errors.As.
+type ValidationError struct {
+	Field   string // Name of the options field, like "PDFStandards".
+	Message string // Description of the problem.
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// pageRangeRegex matches a single page number or page range of the Pages option.
+var pageRangeRegex = regexp.MustCompile(`^(?<start>\d*)(?<range>-(?<end>\d*))?$`)
+
+// validator collects validation errors.
+type validator struct {
+	capabilities Capabilities
+	version      Version
+	errs         []error
+}
+
+// addf adds a validation error for the given field.
+func (v *validator) addf(field, format string, a ...any) {
+	v.errs = append(v.errs, &ValidationError{Field: field, Message: fmt.Sprintf(format, a...)})
+}
+
+// unsupported adds a validation error for a field whose feature isn't supported by the target version.
+func (v *validator) unsupported(field string) {
+	v.addf(field, "not supported by Typst %s", v.version)
+}
+
+// err returns all collected validation errors joined into one.
+func (v *validator) err() error {
+	return errors.Join(v.errs...)
+}
+
+// world validates the options that are shared by all commands which set up a Typst world.
+func (v *validator) world(o worldOptions) {
+	for key := range o.Input {
+		if key == "" {
+			v.addf("Input", "keys must not be empty")
+		}
+		if strings.Contains(key, "=") {
+			v.addf("Input", "key %q must not contain %q", key, "=")
+		}
+	}
+
+	if o.IgnoreEmbeddedFonts && !v.capabilities.IgnoreEmbeddedFonts {
+		v.unsupported("IgnoreEmbeddedFonts")
+	}
+}
+
+// diagnosticFormat validates the given diagnostic format.
+func (v *validator) diagnosticFormat(format DiagnosticFormat) {
+	switch format {
+	case "", DiagnosticFormatShort, DiagnosticFormatHuman:
+	default:
+		v.addf("DiagnosticFormat", "unknown format %q", format)
+	}
+}
+
+// pages validates the syntax of the Pages option.
+func (v *validator) pages(pages string) {
+	for _, part := range strings.Split(pages, ",") {
+		match := pageRangeRegex.FindStringSubmatch(strings.TrimSpace(part))
+		if match == nil {
+			v.addf("Pages", "%q is not a valid page number or page range", part)
+			continue
+		}
+
+		start, end := match[pageRangeRegex.SubexpIndex("start")], match[pageRangeRegex.SubexpIndex("end")]
+		isRange := match[pageRangeRegex.SubexpIndex("range")] != ""
+		if start == "" && (!isRange || end == "") {
+			v.addf("Pages", "%q must have a start or an end", part)
+			continue
+		}
+
+		var startNumber, endNumber int
+		for _, s := range []struct {
+			text   string
+			number *int
+		}{{start, &startNumber}, {end, &endNumber}} {
+			if s.text == "" {
+				continue
+			}
+			n, err := strconv.Atoi(s.text)
+			if err != nil || n < 1 {
+				v.addf("Pages", "%q is not a valid page number, page numbers start at 1", s.text)
+			}
+			*s.number = n
+		}
+
+		if startNumber > 0 && endNumber > 0 && startNumber > endNumber {
+			v.addf("Pages", "page range %q is reversed", part)
+		}
+	}
+}
+
+// knownPDFStandard returns whether the given PDF standard is known to any Typst version.
+func knownPDFStandard(standard PDFStandard) bool {
+	for _, entry := range pdfStandardsSince {
+		if entry.standard == standard {
+			return true
+		}
+	}
+	return false
+}
+
+// pdfStandards validates the given PDF standards, and the combination of them.
+func (v *validator) pdfStandards(standards []PDFStandard) {
+	var version, archive, universal []PDFStandard
+
+	for i, standard := range standards {
+		if !knownPDFStandard(standard) {
+			v.addf("PDFStandards", "unknown standard %q", standard)
+			continue
+		}
+		if !v.capabilities.SupportsPDFStandard(standard) {
+			v.addf("PDFStandards", "standard %q is not supported by Typst %s", standard, v.version)
+		}
+		if slices.Contains(standards[:i], standard) {
+			v.addf("PDFStandards", "standard %q is listed multiple times", standard)
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(string(standard), "a-"):
+			archive = append(archive, standard)
+		case strings.HasPrefix(string(standard), "ua-"):
+			universal = append(universal, standard)
+		default:
+			version = append(version, standard)
+		}
+	}
+
+	if len(version) > 1 {
+		v.addf("PDFStandards", "only one PDF version can be selected, got %v", version)
+	}
+	if len(archive) > 1 {
+		v.addf("PDFStandards", "only one PDF/A standard can be selected, got %v", archive)
+	}
+	if len(universal) > 1 {
+		v.addf("PDFStandards", "only one PDF/UA standard can be selected, got %v", universal)
+	}
+
+	// PDF/A standards are based on specific PDF versions.
+	if len(version) == 1 && len(archive) == 1 {
+		var required PDFStandard
+		switch {
+		case strings.HasPrefix(string(archive[0]), "a-1"):
+			required = PDFStandard1_4
+		case strings.HasPrefix(string(archive[0]), "a-2"), strings.HasPrefix(string(archive[0]), "a-3"):
+			required = PDFStandard1_7
+		case strings.HasPrefix(string(archive[0]), "a-4"):
+			required = PDFStandard2_0
+		}
+		if required != "" && version[0] != required {
+			v.addf("PDFStandards", "standard %q requires PDF version %q, got %q", archive[0], required, version[0])
+		}
+	}
+}
+
+// Validate checks the options for malformed values, and for features that the given Typst version doesn't support.
+//
+// It returns nil if the options are valid, otherwise all problems are joined into one error.
+// Every problem is described by a *typst.ValidationError.
+func (o *OptionsFonts) Validate(version Version) error {
+	v := validator{capabilities: version.Capabilities(), version: version}
+
+	if o.IgnoreEmbeddedFonts && !v.capabilities.IgnoreEmbeddedFonts {
+		v.unsupported("IgnoreEmbeddedFonts")
+	}
+
+	return v.err()
+}
+
+// Validate checks the options for malformed values, and for features that the given Typst version doesn't support.
+//
+// It returns nil if the options are valid, otherwise all problems are joined into one error.
+// Every problem is described by a *typst.ValidationError.
+func (o *OptionsCompile) Validate(version Version) error {
+	v := validator{capabilities: version.Capabilities(), version: version}
+
+	v.world(worldOptions{Input: o.Input, IgnoreEmbeddedFonts: o.IgnoreEmbeddedFonts})
+
+	if (o.Project != nil || len(o.Files) > 0) && o.Root != "" {
+		v.addf("Root", "can't be used together with Project or Files")
+	}
+	for _, name := range slices.Sorted(maps.Keys(o.Files)) {
+		if err := checkProjectPath(name); err != nil {
+			v.addf("Files", "%v", err)
+		}
+	}
+
+	if o.NoPDFTags && !v.capabilities.NoPDFTags {
+		v.unsupported("NoPDFTags")
+	}
+
+	if o.Jobs < 0 {
+		v.addf("Jobs", "must not be negative, got %d", o.Jobs)
+	}
+
+	if o.Pages != "" {
+		if !v.capabilities.Pages {
+			v.unsupported("Pages")
+		}
+		v.pages(o.Pages)
+	}
+
+	switch o.Format {
+	case OutputFormatAuto, OutputFormatPDF, OutputFormatPNG, OutputFormatSVG, OutputFormatHTML:
+		if !v.capabilities.SupportsOutputFormat(o.Format) {
+			v.addf("Format", "format %q is not supported by Typst %s", o.Format, version)
+		}
+	default:
+		v.addf("Format", "unknown format %q", o.Format)
+	}
+
+	if o.PPI < 0 {
+		v.addf("PPI", "must not be negative, got %d", o.PPI)
+	}
+
+	v.pdfStandards(o.PDFStandards)
+	v.diagnosticFormat(o.DiagnosticFormat)
+
+	return v.err()
+}
+
+// Validate checks the options for malformed values, and for features that the given Typst version doesn't support.
+//
+// It returns nil if the options are valid, otherwise all problems are joined into one error.
+// Every problem is described by a *typst.ValidationError.
+func (o *OptionsQuery) Validate(version Version) error {
+	v := validator{capabilities: version.Capabilities(), version: version}
+
+	v.world(worldOptions{Input: o.Input, IgnoreEmbeddedFonts: o.IgnoreEmbeddedFonts})
+
+	if o.Selector == "" {
+		v.addf("Selector", "must not be empty")
+	}
+
+	v.diagnosticFormat(o.DiagnosticFormat)
+
+	return v.err()
+}
+
+// ValidatingCaller wraps another Caller, and validates all options against its Typst version before they are passed on.
+//
+// If the options are invalid, the wrapped caller is not invoked, and the validation error is returned instead.
+// Create it with &typst.ValidatingCaller{Caller: typst.CLI{}}, and don't copy it after first use.
+type ValidatingCaller struct {
+	Caller Caller // The caller that is wrapped.
+
+	// The Typst version that options are validated against.
+	// If nil, the version is detected once via the wrapped caller.
+	Version *Version
+
+	mutex    sync.Mutex
+	detected *Version
+}
+
+// Ensure that ValidatingCaller implements the ContextCaller and QueryCaller interfaces.
+var (
+	_ ContextCaller = &ValidatingCaller{}
+	_ QueryCaller   = &ValidatingCaller{}
+)
+
+// version returns the Typst version to validate against.
+func (c *ValidatingCaller) version(ctx context.Context) (Version, error) {
+	if c.Version != nil {
+		return *c.Version, nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.detected == nil {
+		version, err := DetectVersion(ctx, c.Caller)
+		if err != nil {
+			return Version{}, fmt.Errorf("failed to detect Typst version: %w", err)
+		}
+		c.detected = &version
+	}
+
+	return *c.detected, nil
+}
+
+// VersionString returns the Typst version as a string.
+func (c *ValidatingCaller) VersionString() (string, error) {
+	return c.VersionStringWithContext(context.Background())
+}
+
+// VersionStringWithContext returns the Typst version as a string.
+func (c *ValidatingCaller) VersionStringWithContext(ctx context.Context) (string, error) {
+	return versionStringWithContext(ctx, c.Caller)
+}
+
+// Fonts returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+func (c *ValidatingCaller) Fonts(options *OptionsFonts) ([]string, error) {
+	return c.FontsWithContext(context.Background(), options)
+}
+
+// FontsWithContext validates the options, and returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+func (c *ValidatingCaller) FontsWithContext(ctx context.Context, options *OptionsFonts) ([]string, error) {
+	if options != nil {
+		version, err := c.version(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := options.Validate(version); err != nil {
+			return nil, err
+		}
+	}
+
+	return fontsWithContext(ctx, c.Caller, options)
+}
+
+// Compile takes a Typst document from input, and renders it into the output writer.
+// The options parameter is optional, and can be nil.
+func (c *ValidatingCaller) Compile(input io.Reader, output io.Writer, options *OptionsCompile) error {
+	return c.CompileWithContext(context.Background(), input, output, options)
+}
+
+// CompileWithContext validates the options, takes a Typst document from input, and renders it into the output writer.
+// The options parameter is optional, and can be nil.
+func (c *ValidatingCaller) CompileWithContext(ctx context.Context, input io.Reader, output io.Writer, options *OptionsCompile) error {
+	if options != nil {
+		version, err := c.version(ctx)
+		if err != nil {
+			return err
+		}
+		if err := options.Validate(version); err != nil {
+			return err
+		}
+	}
+
+	return compileWithContext(ctx, c.Caller, input, output, options)
+}
+
+// Query takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The options parameter is mandatory, as it contains the selector.
+func (c *ValidatingCaller) Query(input io.Reader, result any, options *OptionsQuery) error {
+	return c.QueryWithContext(context.Background(), input, result, options)
+}
+
+// QueryWithContext validates the options, takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The options parameter is mandatory, as it contains the selector.
+func (c *ValidatingCaller) QueryWithContext(ctx context.Context, input io.Reader, result any, options *OptionsQuery) error {
+	if options != nil {
+		version, err := c.version(ctx)
+		if err != nil {
+			return err
+		}
+		if err := options.Validate(version); err != nil {
+			return err
+		}
+	}
+
+	return queryWithContext(ctx, c.Caller, input, result, options)
+}
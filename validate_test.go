@@ -0,0 +1,121 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Dadido3/go-typst"
+	"github.com/google/go-cmp/cmp"
+)
+
+// validationFields returns the fields of all validation errors that are joined in err.
+func validationFields(t *testing.T, err error) []string {
+	if err == nil {
+		return nil
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("Expected joined error, got %T: %v", err, err)
+	}
+
+	var fields []string
+	for _, e := range joined.Unwrap() {
+		var validationErr *typst.ValidationError
+		if !errors.As(e, &validationErr) {
+			t.Fatalf("Expected error of type %T, got %T: %v", validationErr, e, e)
+		}
+		fields = append(fields, validationErr.Field)
+	}
+
+	return fields
+}
+
+func TestOptionsCompile_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		options typst.OptionsCompile
+		want    []string
+	}{
+		{"empty", "0.12.0", typst.OptionsCompile{}, nil},
+		{"valid", "0.14.0", typst.OptionsCompile{
+			Input:        map[string]string{"foo": "bar"},
+			Pages:        "1,3-5,7-,-2",
+			Format:       typst.OutputFormatHTML,
+			PDFStandards: []typst.PDFStandard{typst.PDFStandard1_7, typst.PDFStandardA_2B, typst.PDFStandardUA_1},
+		}, nil},
+		{"unsupported", "0.11.0", typst.OptionsCompile{
+			IgnoreEmbeddedFonts: true,
+			NoPDFTags:           true,
+			Pages:               "1",
+			Format:              typst.OutputFormatHTML,
+			PDFStandards:        []typst.PDFStandard{typst.PDFStandardA_3B},
+		}, []string{"IgnoreEmbeddedFonts", "NoPDFTags", "Pages", "Format", "PDFStandards"}},
+		{"malformed", "0.14.0", typst.OptionsCompile{
+			Input:            map[string]string{"a=b": "c"},
+			Jobs:             -1,
+			Pages:            "0,-,5-3,x",
+			Format:           "docx",
+			PPI:              -1,
+			DiagnosticFormat: "json",
+		}, []string{"Input", "Jobs", "Pages", "Pages", "Pages", "Pages", "Format", "PPI", "DiagnosticFormat"}},
+		{"conflicting standards", "0.14.0", typst.OptionsCompile{
+			PDFStandards: []typst.PDFStandard{typst.PDFStandard1_4, typst.PDFStandard2_0, typst.PDFStandardA_1B, typst.PDFStandardA_2B, typst.PDFStandardA_2B},
+		}, []string{"PDFStandards", "PDFStandards", "PDFStandards"}},
+		{"wrong PDF version", "0.14.0", typst.OptionsCompile{
+			PDFStandards: []typst.PDFStandard{typst.PDFStandard1_7, typst.PDFStandardA_4},
+		}, []string{"PDFStandards"}},
+		{"unknown standard", "0.14.0", typst.OptionsCompile{
+			PDFStandards: []typst.PDFStandard{"x-1"},
+		}, []string{"PDFStandards"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := typst.ParseVersion(tt.version)
+			if err != nil {
+				t.Fatalf("Failed to parse version: %v", err)
+			}
+
+			got := validationFields(t, tt.options.Validate(version))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Validate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOptionsFonts_Validate(t *testing.T) {
+	options := typst.OptionsFonts{IgnoreEmbeddedFonts: true}
+
+	if err := options.Validate(typst.Version{Major: 0, Minor: 14}); err != nil {
+		t.Errorf("Validate() returned error: %v", err)
+	}
+	if got := validationFields(t, options.Validate(typst.Version{Major: 0, Minor: 13, Patch: 1})); !cmp.Equal(got, []string{"IgnoreEmbeddedFonts"}) {
+		t.Errorf("Validate() returned unexpected fields %v", got)
+	}
+}
+
+func TestValidatingCaller(t *testing.T) {
+	caller := &typst.ValidatingCaller{Caller: typst.CLI{}, Version: &typst.Version{Major: 0, Minor: 11}}
+
+	var r bytes.Buffer
+	err := caller.Compile(bytes.NewBufferString(`= Hello`), &r, &typst.OptionsCompile{Format: typst.OutputFormatHTML})
+
+	var validationErr *typst.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected error of type %T, got %T: %v", validationErr, err, err)
+	}
+	if validationErr.Field != "Format" {
+		t.Errorf("Expected validation error of field %q, got %q", "Format", validationErr.Field)
+	}
+	if r.Len() != 0 {
+		t.Errorf("Expected no output, got %d bytes", r.Len())
+	}
+}
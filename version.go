@@ -0,0 +1,214 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// versionRegex matches version strings like "typst 0.13.1 (8ace67d9)", "0.14.0-rc.1" or "v0.12.0".
+var versionRegex = regexp.MustCompile(`^(?:typst\s+)?v?(?<major>\d+)\.(?<minor>\d+)\.(?<patch>\d+)(?:-(?<pre>[0-9A-Za-z.-]+))?(?:\s+\((?<commit>[^)]*)\))?$`)
+
+// Version is a parsed Typst version.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+
+	PreRelease string // Pre-release identifier like "rc.1". Empty for regular releases.
+	Commit     string // Hash of the commit that Typst was built from, if known. This is ignored when comparing versions.
+}
+
+// latestVersion is the latest Typst version that is supported, which is also the one of typst.DockerDefaultImage.
+var latestVersion = Version{Major: 0, Minor: 14, Patch: 0}
+
+// ParseVersion parses the given Typst version string.
+//
+// It supports the output of VersionString, like "typst 0.13.1 (8ace67d9)", as well as plain versions like "0.13.1" or "v0.13.1".
+func ParseVersion(s string) (Version, error) {
+	match := versionRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return Version{}, fmt.Errorf("%q is not a valid Typst version", s)
+	}
+
+	var v Version
+	var err error
+	if v.Major, err = strconv.Atoi(match[versionRegex.SubexpIndex("major")]); err != nil {
+		return Version{}, fmt.Errorf("failed to parse major version of %q: %w", s, err)
+	}
+	if v.Minor, err = strconv.Atoi(match[versionRegex.SubexpIndex("minor")]); err != nil {
+		return Version{}, fmt.Errorf("failed to parse minor version of %q: %w", s, err)
+	}
+	if v.Patch, err = strconv.Atoi(match[versionRegex.SubexpIndex("patch")]); err != nil {
+		return Version{}, fmt.Errorf("failed to parse patch version of %q: %w", s, err)
+	}
+	v.PreRelease = match[versionRegex.SubexpIndex("pre")]
+	v.Commit = match[versionRegex.SubexpIndex("commit")]
+
+	return v, nil
+}
+
+// DetectVersion queries the Typst version of the given caller, and parses it.
+func DetectVersion(ctx context.Context, caller Caller) (Version, error) {
+	s, err := versionStringWithContext(ctx, caller)
+	if err != nil {
+		return Version{}, err
+	}
+
+	return ParseVersion(s)
+}
+
+// String returns the version in the form "0.13.1" or "0.14.0-rc.1".
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+
+	return s
+}
+
+// Compare returns -1 if v is older than other, +1 if v is newer than other, and 0 if both are the same version.
+//
+// Pre-releases are older than their regular release.
+// The commit is ignored.
+func (v Version) Compare(other Version) int {
+	if c := cmp.Compare(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+
+	switch {
+	case v.PreRelease == other.PreRelease:
+		return 0
+	case v.PreRelease == "":
+		return 1
+	case other.PreRelease == "":
+		return -1
+	}
+	return comparePreReleases(v.PreRelease, other.PreRelease)
+}
+
+// comparePreReleases compares two pre-release identifiers like "rc.2" and "rc.10" by semantic versioning precedence.
+//
+// The dot separated parts are compared one after another.
+// Numeric parts are compared numerically, and are older than alphanumeric parts.
+// If all parts are equal, the identifier with fewer parts is older.
+func comparePreReleases(a, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.ParseUint(aParts[i], 10, 64)
+		bNum, bErr := strconv.ParseUint(bParts[i], 10, 64)
+
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = cmp.Compare(aNum, bNum)
+		case aErr == nil:
+			c = -1
+		case bErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(aParts[i], bParts[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(len(aParts), len(bParts))
+}
+
+// AtLeast returns whether v is the given version or newer.
+//
+// Pre-releases are treated like their regular release, as they usually already contain all features of it.
+func (v Version) AtLeast(major, minor, patch int) bool {
+	return slices.Compare([]int{v.Major, v.Minor, v.Patch}, []int{major, minor, patch}) >= 0
+}
+
+// Capabilities describes which features a specific Typst version supports.
+type Capabilities struct {
+	OutputFormats []OutputFormat // All supported output formats, except for typst.OutputFormatAuto.
+	PDFStandards  []PDFStandard  // All supported PDF standards.
+
+	IgnoreEmbeddedFonts bool // Whether the --ignore-embedded-fonts flag is supported.
+	NoPDFTags           bool // Whether the --no-pdf-tags flag is supported.
+	Pages               bool // Whether the --pages flag is supported.
+
+	HTMLFeatureFlag bool // Whether HTML export has to be enabled with "--features html".
+}
+
+// pdfStandardsSince contains all PDF standards in the order they are declared, together with the version that introduced them.
+var pdfStandardsSince = []struct {
+	standard PDFStandard
+	since    [3]int
+}{
+	{PDFStandard1_4, [3]int{0, 14, 0}},
+	{PDFStandard1_5, [3]int{0, 14, 0}},
+	{PDFStandard1_6, [3]int{0, 14, 0}},
+	{PDFStandard1_7, [3]int{0, 0, 0}},
+	{PDFStandard2_0, [3]int{0, 14, 0}},
+	{PDFStandardA_1B, [3]int{0, 14, 0}},
+	{PDFStandardA_1A, [3]int{0, 14, 0}},
+	{PDFStandardA_2B, [3]int{0, 0, 0}},
+	{PDFStandardA_2U, [3]int{0, 14, 0}},
+	{PDFStandardA_2A, [3]int{0, 14, 0}},
+	{PDFStandardA_3B, [3]int{0, 13, 0}},
+	{PDFStandardA_3U, [3]int{0, 14, 0}},
+	{PDFStandardA_3A, [3]int{0, 14, 0}},
+	{PDFStandardA_4, [3]int{0, 14, 0}},
+	{PDFStandardA_4F, [3]int{0, 14, 0}},
+	{PDFStandardA_4E, [3]int{0, 14, 0}},
+	{PDFStandardUA_1, [3]int{0, 14, 0}},
+}
+
+// Capabilities returns the features that are supported by this Typst version.
+//
+// This is based on the "Available since" notes of the options, and covers all supported Typst versions.
+func (v Version) Capabilities() Capabilities {
+	c := Capabilities{
+		OutputFormats:       []OutputFormat{OutputFormatPDF, OutputFormatPNG, OutputFormatSVG},
+		IgnoreEmbeddedFonts: v.AtLeast(0, 14, 0),
+		NoPDFTags:           v.AtLeast(0, 14, 0),
+		Pages:               v.AtLeast(0, 12, 0),
+	}
+
+	if v.AtLeast(0, 13, 0) {
+		c.OutputFormats = append(c.OutputFormats, OutputFormatHTML)
+		c.HTMLFeatureFlag = true
+	}
+
+	for _, entry := range pdfStandardsSince {
+		if v.AtLeast(entry.since[0], entry.since[1], entry.since[2]) {
+			c.PDFStandards = append(c.PDFStandards, entry.standard)
+		}
+	}
+
+	return c
+}
+
+// SupportsOutputFormat returns whether the given output format is supported.
+// typst.OutputFormatAuto is always supported.
+func (c Capabilities) SupportsOutputFormat(format OutputFormat) bool {
+	return format == OutputFormatAuto || slices.Contains(c.OutputFormats, format)
+}
+
+// SupportsPDFStandard returns whether the given PDF standard is supported.
+func (c Capabilities) SupportsPDFStandard(standard PDFStandard) bool {
+	return slices.Contains(c.PDFStandards, standard)
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Dadido3/go-typst"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     string
+		want    typst.Version
+		wantErr bool
+	}{
+		{"version string", "typst 0.13.1 (8ace67d9)\n", typst.Version{Major: 0, Minor: 13, Patch: 1, Commit: "8ace67d9"}, false},
+		{"release candidate", "typst 0.14.0-rc.2 (b790c6d5)", typst.Version{Major: 0, Minor: 14, Patch: 0, PreRelease: "rc.2", Commit: "b790c6d5"}, false},
+		{"plain", "0.12.0", typst.Version{Major: 0, Minor: 12, Patch: 0}, false},
+		{"docker tag", "v0.13.0", typst.Version{Major: 0, Minor: 13, Patch: 0}, false},
+		{"invalid", "typst", typst.Version{}, true},
+		{"incomplete", "0.13", typst.Version{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := typst.ParseVersion(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseVersion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseVersion() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"0.13.1", "0.13.1", 0},
+		{"0.13.0", "0.13.1", -1},
+		{"0.14.0", "0.13.1", 1},
+		{"1.0.0", "0.14.0", 1},
+		{"0.14.0-rc.1", "0.14.0", -1},
+		{"0.14.0-rc.2", "0.14.0-rc.1", 1},
+		{"0.14.0-rc.10", "0.14.0-rc.2", 1},
+		{"0.14.0-rc.2", "0.14.0-rc.10", -1},
+		{"0.14.0-beta.2", "0.14.0-rc.1", -1},
+		{"0.14.0-rc.1", "0.14.0-rc.1.1", -1},
+		{"0.14.0-rc.1", "0.14.0-rc.a", -1},
+		{"typst 0.13.1 (8ace67d9)", "0.13.1", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			a, err := typst.ParseVersion(tt.a)
+			if err != nil {
+				t.Fatalf("Failed to parse version: %v", err)
+			}
+			b, err := typst.ParseVersion(tt.b)
+			if err != nil {
+				t.Fatalf("Failed to parse version: %v", err)
+			}
+			if got := a.Compare(b); got != tt.want {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersion_Capabilities(t *testing.T) {
+	tests := []struct {
+		version string
+		want    typst.Capabilities
+	}{
+		{"0.12.0", typst.Capabilities{
+			OutputFormats: []typst.OutputFormat{typst.OutputFormatPDF, typst.OutputFormatPNG, typst.OutputFormatSVG},
+			PDFStandards:  []typst.PDFStandard{typst.PDFStandard1_7, typst.PDFStandardA_2B},
+			Pages:         true,
+		}},
+		{"0.13.1", typst.Capabilities{
+			OutputFormats:   []typst.OutputFormat{typst.OutputFormatPDF, typst.OutputFormatPNG, typst.OutputFormatSVG, typst.OutputFormatHTML},
+			PDFStandards:    []typst.PDFStandard{typst.PDFStandard1_7, typst.PDFStandardA_2B, typst.PDFStandardA_3B},
+			Pages:           true,
+			HTMLFeatureFlag: true,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			v, err := typst.ParseVersion(tt.version)
+			if err != nil {
+				t.Fatalf("Failed to parse version: %v", err)
+			}
+			if got := v.Capabilities(); !cmp.Equal(got, tt.want) {
+				t.Errorf("Capabilities don't match: %s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+
+	c := typst.Version{Major: 0, Minor: 14, Patch: 0}.Capabilities()
+	if !c.IgnoreEmbeddedFonts || !c.NoPDFTags || !c.SupportsPDFStandard(typst.PDFStandardUA_1) {
+		t.Errorf("Typst 0.14.0 is missing capabilities: %+v", c)
+	}
+}
+
+func TestDetectVersion(t *testing.T) {
+	cli := typst.CLI{}
+
+	v, err := typst.DetectVersion(context.Background(), cli)
+	if err != nil {
+		t.Fatalf("Failed to detect Typst version: %v.", err)
+	}
+	if !v.AtLeast(0, 12, 0) {
+		t.Errorf("Detected unsupported Typst version %v.", v)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/Dadido3/go-typst"
+)
+
+// fakeWASMModule builds the fake Typst executable in test-files/wasm-fake-typst as WebAssembly module.
+// It is only built once per test run.
+var fakeWASMModule = sync.OnceValues(func() ([]byte, error) {
+	dir, err := os.MkdirTemp("", "go-typst-wasm-fake-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "typst.wasm")
+	cmd := exec.Command("go", "build", "-o", path, "./test-files/wasm-fake-typst")
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, errors.New(err.Error() + ": " + string(output))
+	}
+
+	return os.ReadFile(path)
+})
+
+// newFakeWASM returns a WASM caller that runs the fake Typst executable in test-files/wasm-fake-typst.
+func newFakeWASM(t *testing.T) *typst.WASM {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("The go command is needed to build the fake WebAssembly module")
+	}
+
+	module, err := fakeWASMModule()
+	if err != nil {
+		t.Fatalf("Failed to build fake WebAssembly module: %v.", err)
+	}
+
+	caller, err := typst.NewWASM(context.Background(), module)
+	if err != nil {
+		t.Fatalf("Failed to create WASM caller: %v.", err)
+	}
+	t.Cleanup(func() { caller.Close(context.Background()) })
+
+	return caller
+}
+
+func TestWASMFake_Args(t *testing.T) {
+	caller := newFakeWASM(t)
+
+	options := &typst.OptionsCompile{Format: typst.OutputFormatSVG, PPI: 144, Custom: []string{"--custom"}}
+
+	var w bytes.Buffer
+	if err := caller.Compile(strings.NewReader("args"), &w, options); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+
+	want := strings.Join(options.Args(), "\n") + "\n"
+	if got := w.String(); got != want {
+		t.Errorf("Got arguments %q, want %q.", got, want)
+	}
+}
+
+func TestWASMFake_Mounts(t *testing.T) {
+	caller := newFakeWASM(t)
+	caller.FS = fstest.MapFS{
+		"included.typ": {Data: []byte("Included\n")},
+	}
+	caller.FontFS = fstest.MapFS{
+		"font.ttf": {Data: []byte("Font\n")},
+	}
+
+	var w bytes.Buffer
+	if err := caller.Compile(strings.NewReader("read included.typ\nread "+typst.WASMFontPath+"/font.ttf"), &w, nil); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+
+	if got, want := w.String(), "Included\nFont\n"; got != want {
+		t.Errorf("Got output %q, want %q.", got, want)
+	}
+}
+
+func TestWASMFake_OutputFile(t *testing.T) {
+	caller := newFakeWASM(t)
+
+	// The output is written into a workspace that is mounted into the module.
+	outputPath := filepath.Join(t.TempDir(), "output.pdf")
+	if err := caller.Compile(strings.NewReader("Hello"), typst.OutputFile(outputPath), nil); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v.", err)
+	}
+	if got, want := string(content), "Hello\n"; got != want {
+		t.Errorf("Got output %q, want %q.", got, want)
+	}
+}
+
+func TestWASMFake_ExitCode(t *testing.T) {
+	caller := newFakeWASM(t)
+
+	var w bytes.Buffer
+	err := caller.Compile(strings.NewReader("exit 3"), &w, nil)
+	if err == nil {
+		t.Fatalf("Expected error, got nil.")
+	}
+
+	var errTypst *typst.Error
+	if !errors.As(err, &errTypst) {
+		t.Fatalf("Expected error of type %T, got %T: %v.", errTypst, err, err)
+	}
+	if len(errTypst.Details) != 1 || errTypst.Details[0].Message != "error: exit requested" {
+		t.Errorf("Unexpected error details: %+v.", errTypst.Details)
+	}
+
+	var errExit interface{ ExitCode() int }
+	if !errors.As(err, &errExit) || errExit.ExitCode() != 3 {
+		t.Errorf("Expected error with exit code 3, got %v.", err)
+	}
+}
+
+func TestWASMFake_Environment(t *testing.T) {
+	t.Setenv("GO_TYPST_ALLOWED", "allowed")
+	t.Setenv("GO_TYPST_SECRET", "secret")
+
+	caller := newFakeWASM(t)
+	caller.FontFS = fstest.MapFS{}
+	caller.Environment = &typst.Environment{
+		Allow:           []string{"GO_TYPST_ALLOWED"},
+		SourceDateEpoch: time.Unix(1700000000, 0),
+		FontPaths:       []string{"/more-fonts"},
+		Vars:            map[string]string{"TYPST_FEATURES": "html"},
+	}
+
+	var w bytes.Buffer
+	if err := caller.Compile(strings.NewReader("env"), &w, nil); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+
+	output := w.String()
+	for _, want := range []string{
+		"GO_TYPST_ALLOWED=allowed",
+		"SOURCE_DATE_EPOCH=1700000000",
+		"TYPST_FONT_PATHS=" + typst.WASMFontPath + ":/more-fonts",
+		"TYPST_FEATURES=html",
+	} {
+		if !strings.Contains(output, want+"\n") {
+			t.Errorf("Environment %q doesn't contain %q.", output, want)
+		}
+	}
+	if strings.Contains(output, "GO_TYPST_SECRET") {
+		t.Errorf("Environment %q contains variable that is not allowed.", output)
+	}
+}
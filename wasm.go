@@ -0,0 +1,239 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// WASMFontPath is the directory where WASM.FontFS is mounted, as seen by Typst.
+const WASMFontPath = "/fonts"
+
+// WASM allows you to invoke commands on a Typst compiler WebAssembly module, which is run inside of the Go process.
+// This needs neither a native Typst installation nor Docker.
+//
+// The module is not provided by this library.
+// It has to be a build of the Typst CLI that targets WASI preview 1 (wasm32-wasip1), as it is invoked with the same command line arguments as the native executable.
+//
+// Typst has no access to the host's file system or network, it can only access files that are provided via FS and FontFS.
+// This also means that paths in options and typst.InputFile are paths inside of FS, and that packages need to be provided via FS and OptionsCompile.PackagePath.
+// typst.OutputFile still refers to a path on the host.
+//
+// Use NewWASM to create one, and call Close once it's not needed anymore.
+type WASM struct {
+	FS     fs.FS // Files that Typst can access. They are mounted at the root of Typst's file system, which is also its working directory. Can be nil.
+	FontFS fs.FS // Additional fonts that are mounted at typst.WASMFontPath and made available to Typst. Can be nil.
+	Hooks  Hooks // Optional hooks that are called before and after every invocation of Typst, see typst.SlogHooks.
+
+	// Optional environment variables of Typst.
+	// Like with containers, only the variables of the current process that are listed in Allow are passed on, and paths refer to Typst's file system.
+	// Typst can't access the network, so the proxy and certificate variables have no effect.
+	Environment *Environment
+
+	runtime  wazero.Runtime
+	module   wazero.CompiledModule
+	versions versionCache // The version of the module, once it has been detected.
+}
+
+// Ensure that WASM implements the ContextCaller and QueryCaller interfaces.
+var (
+	_ ContextCaller = &WASM{}
+	_ QueryCaller   = &WASM{}
+)
+
+// wasmExitError wraps the exit error of a WebAssembly module, so that it implements the exitError interface.
+type wasmExitError struct {
+	*sys.ExitError
+}
+
+func (e wasmExitError) ExitCode() int {
+	return int(e.ExitError.ExitCode())
+}
+
+func (e wasmExitError) Unwrap() error {
+	return e.ExitError
+}
+
+// NewWASM compiles the given Typst WebAssembly module, and returns a caller that runs it.
+//
+// Compiling the module may take a while, so the returned caller should be reused.
+// The given ctx is only used for compilation.
+func NewWASM(ctx context.Context, module []byte) (*WASM, error) {
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx) //nolint:errcheck
+		return nil, fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+
+	compiled, err := runtime.CompileModule(ctx, module)
+	if err != nil {
+		runtime.Close(ctx) //nolint:errcheck
+		return nil, fmt.Errorf("failed to compile Typst WebAssembly module: %w", err)
+	}
+
+	return &WASM{runtime: runtime, module: compiled}, nil
+}
+
+// Close releases all resources of the WebAssembly runtime.
+// The caller can't be used afterwards.
+func (w *WASM) Close(ctx context.Context) error {
+	return w.runtime.Close(ctx)
+}
+
+// run implements the runner interface.
+func (w *WASM) run(ctx context.Context, inv *invocation) error {
+	if w.runtime == nil {
+		return fmt.Errorf("the WASM caller has to be created with typst.NewWASM")
+	}
+
+	fsConfig := wazero.NewFSConfig()
+	if w.FS != nil {
+		fsConfig = fsConfig.WithFSMount(w.FS, "/")
+	}
+	if w.FontFS != nil {
+		fsConfig = fsConfig.WithFSMount(w.FontFS, WASMFontPath)
+	}
+	for _, volume := range inv.volumes {
+		// Volumes use the Docker syntax "host:guest", where the guest path never contains colons.
+		i := strings.LastIndex(volume, ":")
+		fsConfig = fsConfig.WithDirMount(volume[:i], volume[i+1:])
+	}
+
+	config := wazero.NewModuleConfig().
+		WithName(""). // Allows the module to be instantiated multiple times concurrently.
+		WithArgs(append([]string{"typst"}, inv.args...)...).
+		WithFSConfig(fsConfig).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader)
+	fontPaths := ""
+	for _, v := range w.Environment.containerVars() {
+		name, value, _ := strings.Cut(v, "=")
+		if name == "TYPST_FONT_PATHS" {
+			fontPaths = value
+			continue
+		}
+		config = config.WithEnv(name, value)
+	}
+	if w.FontFS != nil {
+		fontPaths = strings.TrimSuffix(WASMFontPath+":"+fontPaths, ":")
+	}
+	if fontPaths != "" {
+		config = config.WithEnv("TYPST_FONT_PATHS", fontPaths)
+	}
+	if inv.stdin != nil {
+		config = config.WithStdin(inv.stdin)
+	}
+	if inv.stdout != nil {
+		config = config.WithStdout(inv.stdout)
+	}
+	if inv.stderr != nil {
+		config = config.WithStderr(inv.stderr)
+	}
+
+	// The module exits when it's done, which also closes it.
+	// Any non-zero exit code is returned as a *sys.ExitError.
+	mod, err := w.runtime.InstantiateModule(ctx, w.module, config)
+	if mod != nil {
+		mod.Close(ctx) //nolint:errcheck
+	}
+	if exitErr, ok := err.(*sys.ExitError); ok {
+		return wasmExitError{exitErr}
+	}
+
+	return err
+}
+
+// newWorkspace implements the runner interface.
+func (w *WASM) newWorkspace(ctx context.Context, parent string) (*workspace, error) {
+	ws, err := newHostWorkspace(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	ws.typstDir = "/go-typst/workspace-" + randomID()
+	ws.container = true
+	ws.volume = ws.hostDir + ":" + ws.typstDir
+
+	return ws, nil
+}
+
+// kind implements the runner interface.
+func (w *WASM) kind() CallerKind {
+	return CallerKindWASM
+}
+
+// hooks implements the runner interface.
+func (w *WASM) hooks() Hooks {
+	return w.Hooks
+}
+
+// versionCache implements the versionCacher interface.
+func (w *WASM) versionCache() *versionCache {
+	return &w.versions
+}
+
+// VersionString returns the Typst version as a string.
+func (w *WASM) VersionString() (string, error) {
+	return w.VersionStringWithContext(context.Background())
+}
+
+// VersionStringWithContext returns the Typst version as a string.
+// The module is aborted once ctx is done.
+func (w *WASM) VersionStringWithContext(ctx context.Context) (string, error) {
+	return versionString(ctx, w)
+}
+
+// Fonts returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+func (w *WASM) Fonts(options *OptionsFonts) ([]string, error) {
+	return w.FontsWithContext(context.Background(), options)
+}
+
+// FontsWithContext returns all fonts that are available to Typst.
+// The options parameter is optional, and can be nil.
+// The module is aborted once ctx is done.
+func (w *WASM) FontsWithContext(ctx context.Context, options *OptionsFonts) ([]string, error) {
+	return fonts(ctx, w, options)
+}
+
+// Compile takes a Typst document from input, and renders it into the output writer.
+// The options parameter is optional, and can be nil.
+func (w *WASM) Compile(input io.Reader, output io.Writer, options *OptionsCompile) error {
+	return w.CompileWithContext(context.Background(), input, output, options)
+}
+
+// CompileWithContext takes a Typst document from input, and renders it into the output writer.
+// The options parameter is optional, and can be nil.
+// The module is aborted once ctx is done.
+func (w *WASM) CompileWithContext(ctx context.Context, input io.Reader, output io.Writer, options *OptionsCompile) error {
+	return compile(ctx, w, input, output, options)
+}
+
+// Query takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+func (w *WASM) Query(input io.Reader, result any, options *OptionsQuery) error {
+	return w.QueryWithContext(context.Background(), input, result, options)
+}
+
+// QueryWithContext takes a Typst document from input, and retrieves the elements that match the selector in options.
+// The JSON result is decoded into result, which works the same as json.Unmarshal.
+// The options parameter is mandatory, as it contains the selector.
+// The module is aborted once ctx is done.
+func (w *WASM) QueryWithContext(ctx context.Context, input io.Reader, result any, options *OptionsQuery) error {
+	return query(ctx, w, input, result, options)
+}
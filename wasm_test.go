@@ -0,0 +1,100 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Dadido3/go-typst"
+)
+
+// newWASM returns a WASM caller for the module at the path in the TYPST_WASM_MODULE environment variable.
+// The test is skipped if the variable is not set, as there is no official Typst WebAssembly module to test against.
+// The plumbing of typst.WASM is tested independently of that with a fake module, see newFakeWASM.
+func newWASM(t *testing.T) *typst.WASM {
+	path := os.Getenv("TYPST_WASM_MODULE")
+	if path == "" {
+		t.Skip("TYPST_WASM_MODULE is not set")
+	}
+
+	module, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read WebAssembly module: %v", err)
+	}
+
+	caller, err := typst.NewWASM(context.Background(), module)
+	if err != nil {
+		t.Fatalf("Failed to create WASM caller: %v", err)
+	}
+	t.Cleanup(func() { caller.Close(context.Background()) })
+
+	return caller
+}
+
+func TestWASM_VersionString(t *testing.T) {
+	caller := newWASM(t)
+
+	v, err := caller.VersionString()
+	if err != nil {
+		t.Fatalf("Failed to get typst version: %v", err)
+	}
+
+	t.Logf("VersionString: %s", v)
+}
+
+func TestWASM_Fonts(t *testing.T) {
+	caller := newWASM(t)
+
+	result, err := caller.Fonts(nil)
+	if err != nil {
+		t.Fatalf("Failed to get available fonts: %v.", err)
+	}
+	if len(result) < 1 {
+		t.Errorf("Unexpected number of detected fonts. Got %d, want >= %d.", len(result), 1)
+	}
+}
+
+func TestWASM_Compile(t *testing.T) {
+	caller := newWASM(t)
+	caller.FS = fstest.MapFS{
+		"included.typ": {Data: []byte(`= Included`)},
+	}
+
+	r := bytes.NewBufferString(`#set page(width: 1in, height: 1in)
+#include "included.typ"`)
+
+	var w bytes.Buffer
+	if err := caller.Compile(r, &w, &typst.OptionsCompile{Format: typst.OutputFormatPNG}); err != nil {
+		t.Fatalf("Failed to compile document: %v.", err)
+	}
+
+	if _, _, err := image.Decode(&w); err != nil {
+		t.Fatalf("Failed to decode image: %v.", err)
+	}
+}
+
+func TestWASM_CompileError(t *testing.T) {
+	caller := newWASM(t)
+
+	r := bytes.NewBufferString(`#assert(false)`)
+
+	var w bytes.Buffer
+	err := caller.Compile(r, &w, nil)
+	if err == nil {
+		t.Fatalf("Expected error, got nil.")
+	}
+
+	var errTypst *typst.Error
+	if !errors.As(err, &errTypst) {
+		t.Fatalf("Expected error of type %T, got %T: %v.", errTypst, err, err)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+//go:build unix
+
+package typst_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Dadido3/go-typst"
+)
+
+// fakeWatchTypst is a fake Typst executable that compiles twice in watch mode, and then exits with an error.
+const fakeWatchTypst = `printf 'watching main.typ\nwriting to main.pdf\n\n[12:00:00] compiled successfully in 1.00ms\n\n' >&2
+sleep 0.3
+printf 'watching main.typ\nwriting to main.pdf\n\n[12:00:01] compiled successfully in 1.00ms\n\n' >&2
+sleep 0.3
+echo 'error: file not found (searched at main.typ)' >&2
+exit 1`
+
+func TestWatch_OutputPath(t *testing.T) {
+	fakeRuntime(t, "typst-watch", fakeWatchTypst)
+
+	w, err := typst.CLI{ExecutablePath: "typst-watch"}.Watch(context.Background(), "main.typ", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to start watcher: %v.", err)
+	}
+	defer w.Close()
+
+	// Typst derives the output path from the input path.
+	event := nextWatchEvent(t, w)
+	if event.Kind != typst.WatchEventCompiled || event.OutputPath != "main.pdf" {
+		t.Errorf("Got event %v with output path %q, want %v with %q.", event.Kind, event.OutputPath, typst.WatchEventCompiled, "main.pdf")
+	}
+}
+
+func TestWatch_Abandoned(t *testing.T) {
+	fakeRuntime(t, "typst-watch", fakeWatchTypst)
+
+	w, err := typst.CLI{ExecutablePath: "typst-watch"}.Watch(context.Background(), "main.typ", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to start watcher: %v.", err)
+	}
+
+	// Stop receiving events, and don't close the watcher.
+	// Once Typst has exited, the last event has to be delivered without anybody receiving it.
+	time.Sleep(2 * time.Second)
+
+	event := nextWatchEvent(t, w)
+	if event.Kind != typst.WatchEventExited || event.Err == nil {
+		t.Errorf("Got event %v with error %v, want %v with an error.", event.Kind, event.Err, typst.WatchEventExited)
+	}
+	if _, ok := <-w.Events(); ok {
+		t.Errorf("Expected the event channel to be closed.")
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// WatchEventKind describes what happened in a WatchEvent.
+type WatchEventKind int
+
+const (
+	WatchEventCompiled WatchEventKind = iota // The document was compiled successfully, maybe with warnings.
+	WatchEventFailed                         // The document failed to compile.
+	WatchEventExited                         // The Typst process has exited. This is always the last event.
+)
+
+// String returns a human readable representation of the event kind.
+func (k WatchEventKind) String() string {
+	switch k {
+	case WatchEventCompiled:
+		return "compiled"
+	case WatchEventFailed:
+		return "failed"
+	case WatchEventExited:
+		return "exited"
+	}
+	return fmt.Sprintf("WatchEventKind(%d)", int(k))
+}
+
+// WatchEvent is emitted by a Watcher whenever Typst has (re)compiled a document, or when Typst has exited.
+type WatchEvent struct {
+	Kind WatchEventKind
+
+	// The path of the output file as seen by Typst.
+	// If no output path was given to Watch, this is the path that Typst has derived from the input path.
+	// Only set for WatchEventCompiled, and may be empty if Typst didn't report the path.
+	OutputPath string
+
+	// The diagnostics that Typst emitted during the compilation.
+	// This contains warnings for WatchEventCompiled, and errors (and maybe warnings) for WatchEventFailed.
+	Details []ErrorDetails
+
+	// For WatchEventFailed this contains a *typst.Error with the same details as above.
+	// For WatchEventExited this contains the reason why Typst has exited, or nil if the watcher was closed.
+	Err error
+}
+
+// watchQuietPeriod is the time after the last output of Typst until the diagnostics of a compilation are considered complete.
+const watchQuietPeriod = 100 * time.Millisecond
+
+// watchStatusRegex matches the status lines that Typst prints in watch mode.
+var watchStatusRegex = regexp.MustCompile(`^\[\d{2}:\d{2}:\d{2}\] (?<status>.+)$`)
+
+// ansiEscapeRegex matches ANSI escape sequences, like the ones that are used to clear the terminal.
+var ansiEscapeRegex = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// Watcher runs Typst in watch mode, and reports every (re)compilation of the watched document.
+//
+// Use the Watch method of a caller to create a Watcher.
+type Watcher struct {
+	events chan WatchEvent
+	cancel context.CancelFunc
+	closed atomic.Bool
+	done   chan struct{}
+}
+
+// watch starts Typst in watch mode with the Typst executable behind r.
+func watch(ctx context.Context, r runner, input, output string, options *OptionsCompile) (*Watcher, error) {
+	if input == "" {
+		return nil, fmt.Errorf("the input path is empty")
+	}
+	if options == nil {
+		options = new(OptionsCompile)
+	}
+	if options.Project != nil || len(options.Files) > 0 {
+		return nil, fmt.Errorf("the options Project and Files are not supported in watch mode")
+	}
+
+	args, err := compileArgs(ctx, r, options, "w", input, output)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	w := &Watcher{
+		events: make(chan WatchEvent, 1), // Room for the last event, see deliverLast.
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	pr, pw := io.Pipe()
+	runErr := make(chan error, 1)
+	exited := make(chan struct{})
+
+	go func() {
+		err := r.run(ctx, &invocation{args: args, stderr: pw})
+		close(exited)
+		pw.Close()
+		runErr <- err
+	}()
+
+	go func() {
+		defer close(w.done)
+		defer close(w.events)
+		defer cancel()
+
+		remaining := w.process(ctx, pr, output, exited)
+		err := <-runErr
+
+		var dockerErr *DockerError
+		var exitErr *exec.ExitError
+		switch {
+		case w.closed.Load():
+			err = nil
+		case ctx.Err() != nil:
+			err = fmt.Errorf("typst invocation was aborted: %w", ctx.Err())
+		case errors.As(err, &dockerErr):
+			// Errors of the container runtime itself are not Typst errors.
+		case errors.As(err, &exitErr):
+			err = ParseStderr(remaining+"\n", err)
+		case err == nil:
+			err = fmt.Errorf("typst has stopped watching unexpectedly")
+		}
+
+		w.deliverLast(WatchEvent{Kind: WatchEventExited, Err: err})
+	}()
+
+	return w, nil
+}
+
+// deliverLast delivers the last event without blocking, even if nobody receives events anymore.
+//
+// The event channel has room for one event.
+// If that is still occupied by an event that isn't received in time, that event is discarded in favor of the last one.
+func (w *Watcher) deliverLast(event WatchEvent) {
+	timer := time.NewTimer(watchQuietPeriod)
+	defer timer.Stop()
+
+	select {
+	case w.events <- event:
+		return
+	case <-timer.C:
+	}
+
+	select {
+	case <-w.events:
+	default:
+	}
+
+	// This can't block, as there is no other sender, and the channel has room for one event now.
+	w.events <- event
+}
+
+// process reads the stderr output of Typst and emits events until EOF.
+// It returns any output that couldn't be associated with a compilation.
+// The exited channel has to be closed once Typst has exited.
+func (w *Watcher) process(ctx context.Context, stderr io.Reader, output string, exited <-chan struct{}) string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			lines <- ansiEscapeRegex.ReplaceAllString(scanner.Text(), "")
+		}
+		// Drain in case of an error, so that Typst doesn't block.
+		io.Copy(io.Discard, stderr) //nolint:errcheck
+	}()
+
+	var pending *WatchEvent // The event of the current compilation, waiting for its diagnostics.
+	var diagnostics strings.Builder
+	var other strings.Builder // Output that isn't associated with any compilation.
+
+	// Typst derives the output path from the input path if there is none.
+	outputPath := output
+
+	// flush delivers the pending event.
+	flush := func() {
+		if pending == nil {
+			return
+		}
+		event := *pending
+		pending = nil
+
+		// The parser expects every block to be terminated by an empty line.
+		raw := strings.Trim(diagnostics.String(), "\n") + "\n\n"
+		if typstErr, ok := ParseStderr(raw, nil).(*Error); ok {
+			event.Details = typstErr.Details
+			if event.Kind == WatchEventFailed {
+				event.Err = typstErr
+			}
+		}
+		diagnostics.Reset()
+
+		select {
+		case w.events <- event:
+			return
+		case <-ctx.Done():
+			return
+		case <-exited:
+		}
+
+		// Once Typst has exited, there is no need to wait for a receiver for longer than the quiet period.
+		select {
+		case w.events <- event:
+		case <-ctx.Done():
+		case <-time.After(watchQuietPeriod):
+		}
+	}
+
+	timer := time.NewTimer(watchQuietPeriod)
+	defer timer.Stop()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				flush()
+				return other.String()
+			}
+			timer.Reset(watchQuietPeriod)
+
+			switch {
+			case strings.HasPrefix(line, "watching "):
+				// Typst has started a new compilation, or is about to.
+				flush()
+			case strings.HasPrefix(line, "writing to "):
+				if output == "" {
+					outputPath = strings.TrimPrefix(line, "writing to ")
+				}
+			case strings.HasPrefix(line, "serving at "):
+			case watchStatusRegex.MatchString(line):
+				flush()
+				status := watchStatusRegex.FindStringSubmatch(line)[watchStatusRegex.SubexpIndex("status")]
+				switch {
+				case strings.HasPrefix(status, "compiled successfully"), strings.HasPrefix(status, "compiled with warnings"):
+					pending = &WatchEvent{Kind: WatchEventCompiled, OutputPath: outputPath}
+				case strings.HasPrefix(status, "compiled with errors"):
+					pending = &WatchEvent{Kind: WatchEventFailed}
+				}
+			case pending != nil:
+				diagnostics.WriteString(line + "\n")
+			default:
+				other.WriteString(line + "\n")
+			}
+
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+// Events returns the channel that all events are delivered to.
+//
+// The channel has to be drained, otherwise the Typst process will block.
+// It is closed after the WatchEventExited event has been delivered.
+// Once Typst has exited, events that aren't received in time are discarded, so that the Watcher can finish even if the channel isn't drained anymore.
+// The WatchEventExited event is always delivered.
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Close stops the Typst process and waits until it has exited.
+//
+// Any events that haven't been received at that point are discarded.
+func (w *Watcher) Close() error {
+	w.closed.Store(true)
+	w.cancel()
+
+	// Discard any remaining events, so that the processing goroutine can finish.
+	for range w.events {
+	}
+	<-w.done
+
+	return nil
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dadido3/go-typst"
+)
+
+// nextWatchEvent returns the next event of the watcher, or fails the test after a timeout.
+func nextWatchEvent(t *testing.T, w *typst.Watcher) typst.WatchEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-w.Events():
+		if !ok {
+			t.Fatalf("Event channel was closed unexpectedly.")
+		}
+		return event
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Timed out waiting for watch event.")
+	}
+
+	return typst.WatchEvent{}
+}
+
+func TestCLI_Watch(t *testing.T) {
+	dir := t.TempDir()
+	inputPath, outputPath := filepath.Join(dir, "main.typ"), filepath.Join(dir, "main.pdf")
+
+	if err := os.WriteFile(inputPath, []byte("= Hello world"), 0644); err != nil {
+		t.Fatalf("Failed to write input file: %v.", err)
+	}
+
+	cli := typst.CLI{}
+
+	w, err := cli.Watch(context.Background(), inputPath, outputPath, nil)
+	if err != nil {
+		t.Fatalf("Failed to start watcher: %v.", err)
+	}
+	defer w.Close()
+
+	event := nextWatchEvent(t, w)
+	if event.Kind != typst.WatchEventCompiled {
+		t.Fatalf("Unexpected event %v: %v.", event.Kind, event.Err)
+	}
+	if _, err := os.Stat(event.OutputPath); err != nil {
+		t.Errorf("Output file doesn't exist: %v.", err)
+	}
+
+	// Introduce an error, and wait for Typst to pick it up.
+	if err := os.WriteFile(inputPath, []byte("#assert(1 < 1, message: \"Test\")"), 0644); err != nil {
+		t.Fatalf("Failed to write input file: %v.", err)
+	}
+
+	event = nextWatchEvent(t, w)
+	if event.Kind != typst.WatchEventFailed {
+		t.Fatalf("Unexpected event %v: %v.", event.Kind, event.Err)
+	}
+	var errTypst *typst.Error
+	if !errors.As(event.Err, &errTypst) {
+		t.Fatalf("Expected error type %T, got %T: %v.", errTypst, event.Err, event.Err)
+	}
+	if len(errTypst.Details) != 1 || errTypst.Details[0].Line != 1 {
+		t.Errorf("Unexpected error details: %v.", errTypst.Details)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Errorf("Failed to close watcher: %v.", err)
+	}
+}
+
+func TestCLI_WatchContext(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "main.typ")
+
+	if err := os.WriteFile(inputPath, []byte("= Hello world"), 0644); err != nil {
+		t.Fatalf("Failed to write input file: %v.", err)
+	}
+
+	cli := typst.CLI{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w, err := cli.Watch(ctx, inputPath, "", nil)
+	if err != nil {
+		t.Fatalf("Failed to start watcher: %v.", err)
+	}
+
+	if event := nextWatchEvent(t, w); event.Kind != typst.WatchEventCompiled {
+		t.Fatalf("Unexpected event %v: %v.", event.Kind, event.Err)
+	}
+
+	cancel()
+
+	event := nextWatchEvent(t, w)
+	if event.Kind != typst.WatchEventExited {
+		t.Fatalf("Unexpected event %v: %v.", event.Kind, event.Err)
+	}
+	if !errors.Is(event.Err, context.Canceled) {
+		t.Errorf("Expected error wrapping %v, got %v.", context.Canceled, event.Err)
+	}
+}
+
+func TestCLI_WatchMissingInput(t *testing.T) {
+	cli := typst.CLI{}
+
+	w, err := cli.Watch(context.Background(), filepath.Join(t.TempDir(), "missing.typ"), "", nil)
+	if err != nil {
+		t.Fatalf("Failed to start watcher: %v.", err)
+	}
+	defer w.Close()
+
+	event := nextWatchEvent(t, w)
+	if event.Kind != typst.WatchEventExited {
+		t.Fatalf("Unexpected event %v: %v.", event.Kind, event.Err)
+	}
+	if event.Err == nil {
+		t.Errorf("Expected error, but got nil.")
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2025 David Vogel
+//
+// This software is released under the MIT License.
+// https://opensource.org/licenses/MIT
+
+package typst
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// workspace is a temporary directory that is shared between the host and Typst.
+//
+// Depending on the caller, Typst sees the directory directly, as a mounted volume, or as a copy inside of a container.
+type workspace struct {
+	hostDir   string // Path of the directory on the host.
+	typstDir  string // Path of the directory as seen by Typst.
+	container bool   // Whether typstDir is a path inside of a container, which always uses forward slashes.
+	volume    string // Volume that has to be mounted for Typst to see the directory, if any.
+
+	push   func(ctx context.Context) error // Optional function that copies the content of the directory from the host to Typst's side.
+	pull   func(ctx context.Context) error // Optional function that copies the content of the directory from Typst's side to the host.
+	remove func() error                    // Optional function that removes any resources on Typst's side.
+}
+
+// newHostWorkspace creates a new workspace with a temporary host directory inside of parent.
+func newHostWorkspace(parent string) (*workspace, error) {
+	hostDir, err := os.MkdirTemp(parent, ".go-typst-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	// Typst may need an absolute path, as it can run in a different working directory.
+	if hostDir, err = filepath.Abs(hostDir); err != nil {
+		os.RemoveAll(hostDir) //nolint:errcheck
+		return nil, fmt.Errorf("failed to get absolute path of temporary directory: %w", err)
+	}
+
+	return &workspace{hostDir: hostDir, typstDir: hostDir}, nil
+}
+
+// newSharedHostWorkspace creates a new workspace whose host directory can be written by any user, like a user inside of a container.
+//
+// The host directory is sticky, and it is placed inside of a private directory.
+// This way other users on the host can't reach it, while it can still be bind-mounted into a container.
+func newSharedHostWorkspace(parent string) (*workspace, error) {
+	ws, err := newHostWorkspace(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	privateDir := ws.hostDir
+	ws.hostDir = filepath.Join(privateDir, "workspace")
+	ws.typstDir = ws.hostDir
+	ws.remove = func() error {
+		if err := os.RemoveAll(privateDir); err != nil {
+			return fmt.Errorf("failed to remove temporary directory: %w", err)
+		}
+		return nil
+	}
+
+	// The mode is set explicitly, as Mkdir is subject to the umask.
+	if err := os.Mkdir(ws.hostDir, 0700); err != nil {
+		ws.close() //nolint:errcheck
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	if err := os.Chmod(ws.hostDir, 0777|os.ModeSticky); err != nil {
+		ws.close() //nolint:errcheck
+		return nil, fmt.Errorf("failed to change permissions of temporary directory: %w", err)
+	}
+
+	return ws, nil
+}
+
+// typstPath returns the path of the given file inside of the workspace, as seen by Typst.
+func (w *workspace) typstPath(name string) string {
+	if w.container {
+		return path.Join(w.typstDir, name)
+	}
+	return filepath.Join(w.typstDir, name)
+}
+
+// close removes the workspace with all of its content.
+func (w *workspace) close() error {
+	var errs []error
+	if w.remove != nil {
+		if err := w.remove(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := os.RemoveAll(w.hostDir); err != nil {
+		errs = append(errs, fmt.Errorf("failed to remove temporary directory: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// moveFiles renames all files inside of the workspace's host directory into the given directory.
+// Renaming is atomic, as long as the destination is on the same file system.
+func (w *workspace) moveFiles(dir string) error {
+	entries, err := os.ReadDir(w.hostDir)
+	if err != nil {
+		return fmt.Errorf("failed to read temporary directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := os.Rename(filepath.Join(w.hostDir, entry.Name()), filepath.Join(dir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to move output file into place: %w", err)
+		}
+	}
+
+	return nil
+}